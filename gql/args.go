@@ -1,10 +1,39 @@
 package gql
 
 import (
+	"go-graphql-cloud-api/postgres"
+	"go-graphql-cloud-api/scalar"
+
 	"github.com/graphql-go/graphql"
 )
 
+// Input objects are created once so every input type is only defined once in the schema
+var (
+	LanguageTextInput  = graphql.NewInputObject(LanguageTextArgs)
+	LanguageJsonInput  = graphql.NewInputObject(LanguageJsonArgs)
+	PaymentMethodInput = graphql.NewInputObject(PaymentMethodArgs)
+	ProductInput       = graphql.NewInputObject(ProductArgs)
+	StoreInput         = graphql.NewInputObject(StoreArgs)
+	VendorInput        = graphql.NewInputObject(VendorArgs)
+)
+
+// LanguageTextArgs describes a graphql args containing the text of a single language
+var LanguageTextArgs = graphql.InputObjectConfig{
+	Name: "LanguageTextArgs",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"lang": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"text": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+	},
+}
+
+// LanguageJsonArgs describes a graphql args containing a LanguageJson
 var LanguageJsonArgs = graphql.InputObjectConfig{
+	Name:        "LanguageJsonArgs",
+	Description: "Texts by language, on update they are merged into the existing languages and an empty text removes a language",
 	Fields: graphql.InputObjectConfigFieldMap{
 		"en": &graphql.InputObjectFieldConfig{
 			Type: graphql.String,
@@ -12,14 +41,18 @@ var LanguageJsonArgs = graphql.InputObjectConfig{
 		"zh": &graphql.InputObjectFieldConfig{
 			Type: graphql.String,
 		},
+		"entries": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewList(graphql.NewNonNull(LanguageTextInput)),
+		},
 	},
 }
 
+// VendorArgs describes a graphql args containing a Vendor
 var VendorArgs = graphql.InputObjectConfig{
 	Name: "VendorArgs",
 	Fields: graphql.InputObjectConfigFieldMap{
 		"id": &graphql.InputObjectFieldConfig{
-			Type: graphql.String,
+			Type: scalar.UUIDScalar,
 		},
 		"name": &graphql.InputObjectFieldConfig{
 			Type: graphql.String,
@@ -28,20 +61,20 @@ var VendorArgs = graphql.InputObjectConfig{
 			Type: graphql.String,
 		},
 		"products": &graphql.InputObjectFieldConfig{
-			Type: graphql.NewInputObject(ProductArgs),
+			Type: graphql.NewList(ProductInput),
 		},
 		"stores": &graphql.InputObjectFieldConfig{
-			Type: graphql.NewInputObject(StoreArgs),
+			Type: graphql.NewList(StoreInput),
 		},
 	},
 }
 
-// Product describes a graphql args containing a Product
+// ProductArgs describes a graphql args containing a Product
 var ProductArgs = graphql.InputObjectConfig{
 	Name: "ProductArgs",
 	Fields: graphql.InputObjectConfigFieldMap{
 		"id": &graphql.InputObjectFieldConfig{
-			Type: graphql.String,
+			Type: scalar.UUIDScalar,
 		},
 		"photo": &graphql.InputObjectFieldConfig{
 			Type: graphql.String,
@@ -56,32 +89,33 @@ var ProductArgs = graphql.InputObjectConfig{
 			Type: graphql.String,
 		},
 		"descriptions": &graphql.InputObjectFieldConfig{
-			Type: graphql.NewInputObject(LanguageJsonArgs),
+			Type: LanguageJsonInput,
 		},
 		"brand_names": &graphql.InputObjectFieldConfig{
-			Type: graphql.NewInputObject(LanguageJsonArgs),
+			Type: LanguageJsonInput,
 		},
 		"names": &graphql.InputObjectFieldConfig{
-			Type: graphql.NewInputObject(LanguageJsonArgs),
+			Type: LanguageJsonInput,
 		},
 		"optional_data": &graphql.InputObjectFieldConfig{
-			Type: graphql.NewInputObject(LanguageJsonArgs),
+			Type:        scalar.JSONObjectScalar,
+			Description: "Data of any JSON type, on update its keys are merged into the existing keys and a null value removes a key",
+		},
+		"vendor_id": &graphql.InputObjectFieldConfig{
+			Type: scalar.UUIDScalar,
+		},
+		"supplier_id": &graphql.InputObjectFieldConfig{
+			Type: scalar.UUIDScalar,
 		},
 	},
 }
 
-// Store describes a graphql args containing a Store
+// StoreArgs describes a graphql args containing a Store
 var StoreArgs = graphql.InputObjectConfig{
 	Name: "StoreArgs",
 	Fields: graphql.InputObjectConfigFieldMap{
 		"id": &graphql.InputObjectFieldConfig{
-			Type: graphql.String,
-		},
-		"created_at": &graphql.InputObjectFieldConfig{
-			Type: graphql.String,
-		},
-		"updated_at": &graphql.InputObjectFieldConfig{
-			Type: graphql.String,
+			Type: scalar.UUIDScalar,
 		},
 		"mongo_id": &graphql.InputObjectFieldConfig{
 			Type: graphql.String,
@@ -99,22 +133,236 @@ var StoreArgs = graphql.InputObjectConfig{
 			Type: graphql.String,
 		},
 		"last_online_at": &graphql.InputObjectFieldConfig{
-			Type: graphql.DateTime,
+			Type: scalar.DateTimeScalar,
 		},
 		"last_get": &graphql.InputObjectFieldConfig{
-			Type: graphql.DateTime,
+			Type: scalar.DateTimeScalar,
 		},
 		"last_refill": &graphql.InputObjectFieldConfig{
-			Type: graphql.DateTime,
+			Type: scalar.DateTimeScalar,
 		},
 		"last_reset": &graphql.InputObjectFieldConfig{
-			Type: graphql.DateTime,
+			Type: scalar.DateTimeScalar,
 		},
 		"last_sync": &graphql.InputObjectFieldConfig{
-			Type: graphql.DateTime,
+			Type: scalar.DateTimeScalar,
 		},
 		"unsubmitted_order_count": &graphql.InputObjectFieldConfig{
 			Type: graphql.Int,
 		},
+		"vendor_id": &graphql.InputObjectFieldConfig{
+			Type: scalar.UUIDScalar,
+		},
 	},
 }
+
+// PaymentMethodArgs describes a graphql args containing a PaymentMethod
+var PaymentMethodArgs = graphql.InputObjectConfig{
+	Name: "PaymentMethodArgs",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"mongo_id": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"code": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"name": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"module": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"module_channel": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"order_index": &graphql.InputObjectFieldConfig{
+			Type: graphql.Int,
+		},
+	},
+}
+
+// VendorFilterArgs describes a graphql args narrowing down a list of vendors
+var VendorFilterArgs = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "VendorFilter",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name_contains": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"created_after": &graphql.InputObjectFieldConfig{
+			Type: scalar.DateTimeScalar,
+		},
+		"created_before": &graphql.InputObjectFieldConfig{
+			Type: scalar.DateTimeScalar,
+		},
+	},
+})
+
+// VendorOrderField describes a graphql enum containing the fields vendors can be ordered by
+var VendorOrderField = graphql.NewEnum(graphql.EnumConfig{
+	Name: "VendorOrderField",
+	Values: graphql.EnumValueConfigMap{
+		"CREATED_AT": &graphql.EnumValueConfig{
+			Value: "created_at",
+		},
+		"NAME": &graphql.EnumValueConfig{
+			Value: "name",
+		},
+	},
+})
+
+// ProductFilterArgs describes a graphql args narrowing down a list of products
+var ProductFilterArgs = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "ProductFilter",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"vendor_id": &graphql.InputObjectFieldConfig{
+			Type: scalar.UUIDScalar,
+		},
+		"supplier_id": &graphql.InputObjectFieldConfig{
+			Type: scalar.UUIDScalar,
+		},
+		"is_virtual_product": &graphql.InputObjectFieldConfig{
+			Type: graphql.Boolean,
+		},
+		"code": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+	},
+})
+
+// SupplierFilterArgs describes a graphql args narrowing down a list of suppliers
+var SupplierFilterArgs = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "SupplierFilter",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name_contains": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"code": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+	},
+})
+
+// SupplierOrderField describes a graphql enum containing the fields suppliers can be ordered by
+var SupplierOrderField = graphql.NewEnum(graphql.EnumConfig{
+	Name: "SupplierOrderField",
+	Values: graphql.EnumValueConfigMap{
+		"CREATED_AT": &graphql.EnumValueConfig{
+			Value: "created_at",
+		},
+		"NAME": &graphql.EnumValueConfig{
+			Value: "name",
+		},
+	},
+})
+
+// SlotRefillArgs describes a graphql args containing the refill of a slot,
+// an omitted quantity fills the slot up to its capacity
+var SlotRefillArgs = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "SlotRefillArgs",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"slot": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"quantity": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Int,
+			Description: "At most the capacity of the slot, which a new slot must be given. The slot is filled up when it is unset",
+		},
+		"product_id": &graphql.InputObjectFieldConfig{
+			Type:        scalar.UUIDScalar,
+			Description: "A product of the vendor of the store",
+		},
+		"capacity": &graphql.InputObjectFieldConfig{
+			Type: graphql.Int,
+		},
+		"price": &graphql.InputObjectFieldConfig{
+			Type:        scalar.MoneyScalar,
+			Description: "The price of a unit, in the configured currency",
+		},
+	},
+})
+
+// OrderItemArgs describes a graphql args containing an item of an order, it
+// is taken from its slot or else from the fullest slot stocked with its product
+var OrderItemArgs = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "OrderItemArgs",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"product_id": &graphql.InputObjectFieldConfig{
+			Type: scalar.UUIDScalar,
+		},
+		"slot": &graphql.InputObjectFieldConfig{
+			Type: graphql.Int,
+		},
+		"quantity": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"unit_price": &graphql.InputObjectFieldConfig{
+			Type:        scalar.MoneyScalar,
+			Description: "The price of a unit, in the configured currency",
+		},
+	},
+})
+
+// OrderArgs describes a graphql args containing an order buffered by a store
+var OrderArgs = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "OrderArgs",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"client_order_id": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"payment_method_id": &graphql.InputObjectFieldConfig{
+			Type: scalar.UUIDScalar,
+		},
+		"ordered_at": &graphql.InputObjectFieldConfig{
+			Type: scalar.DateTimeScalar,
+		},
+		"items": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(OrderItemArgs))),
+		},
+	},
+})
+
+// SalesReportGroup describes a graphql enum containing the dimensions a sales
+// report can be grouped by
+var SalesReportGroup = graphql.NewEnum(graphql.EnumConfig{
+	Name: "SalesReportGroup",
+	Values: graphql.EnumValueConfigMap{
+		"STORE": &graphql.EnumValueConfig{
+			Value: postgres.SalesByStore,
+		},
+		"PRODUCT": &graphql.EnumValueConfig{
+			Value: postgres.SalesByProduct,
+		},
+		"DAY": &graphql.EnumValueConfig{
+			Value: postgres.SalesByDay,
+		},
+		"PAYMENT_METHOD": &graphql.EnumValueConfig{
+			Value: postgres.SalesByPaymentMethod,
+		},
+	},
+})
+
+// StoreFilterArgs describes a graphql args narrowing down a list of stores
+var StoreFilterArgs = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "StoreFilter",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"vendor_id": &graphql.InputObjectFieldConfig{
+			Type: scalar.UUIDScalar,
+		},
+		"code": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+	},
+})
+
+// TimestampOrderField describes a graphql enum containing the timestamps products and stores can be ordered by
+var TimestampOrderField = graphql.NewEnum(graphql.EnumConfig{
+	Name: "TimestampOrderField",
+	Values: graphql.EnumValueConfigMap{
+		"CREATED_AT": &graphql.EnumValueConfig{
+			Value: "created_at",
+		},
+		"UPDATED_AT": &graphql.EnumValueConfig{
+			Value: "updated_at",
+		},
+	},
+})
@@ -0,0 +1,174 @@
+package gql
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"go-graphql-cloud-api/postgres"
+
+	"github.com/graphql-go/graphql"
+)
+
+// connection is the source of a Relay style connection type
+type connection struct {
+	Edges      []edge   `json:"edges"`
+	PageInfo   pageInfo `json:"pageInfo"`
+	TotalCount int      `json:"totalCount"`
+}
+
+type edge struct {
+	Cursor string      `json:"cursor"`
+	Node   interface{} `json:"node"`
+}
+
+type pageInfo struct {
+	HasNextPage     bool   `json:"hasNextPage"`
+	HasPreviousPage bool   `json:"hasPreviousPage"`
+	StartCursor     string `json:"startCursor"`
+	EndCursor       string `json:"endCursor"`
+}
+
+// PageInfo describes a graphql object containing the position of a connection page
+var PageInfo = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PageInfo",
+	Fields: graphql.Fields{
+		"hasNextPage": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Boolean),
+		},
+		"hasPreviousPage": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Boolean),
+		},
+		"startCursor": &graphql.Field{
+			Type: graphql.String,
+		},
+		"endCursor": &graphql.Field{
+			Type: graphql.String,
+		},
+	},
+})
+
+// OrderDirection describes a graphql enum containing the direction of an order
+var OrderDirection = graphql.NewEnum(graphql.EnumConfig{
+	Name: "OrderDirection",
+	Values: graphql.EnumValueConfigMap{
+		"ASC": &graphql.EnumValueConfig{
+			Value: "ASC",
+		},
+		"DESC": &graphql.EnumValueConfig{
+			Value: "DESC",
+		},
+	},
+})
+
+// newConnection returns the connection type for a list of node
+func newConnection(node *graphql.Object) *graphql.Object {
+	nodeEdge := graphql.NewObject(graphql.ObjectConfig{
+		Name: node.Name() + "Edge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"node": &graphql.Field{
+				Type: node,
+			},
+		},
+	})
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: node.Name() + "Connection",
+		Fields: graphql.Fields{
+			"edges": &graphql.Field{
+				Type: graphql.NewList(nodeEdge),
+			},
+			"pageInfo": &graphql.Field{
+				Type: graphql.NewNonNull(PageInfo),
+			},
+			"totalCount": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+		},
+	})
+}
+
+// connectionArgs returns the pagination args of a connection field, extended
+// with orderBy of the given enum and any extra args
+func connectionArgs(orderBy *graphql.Enum, extra graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	args := graphql.FieldConfigArgument{
+		"first": &graphql.ArgumentConfig{
+			Type:         graphql.Int,
+			DefaultValue: postgres.DefaultPageSize,
+		},
+		"after": &graphql.ArgumentConfig{
+			Type: graphql.String,
+		},
+		"orderBy": &graphql.ArgumentConfig{
+			Type:         orderBy,
+			DefaultValue: "created_at",
+		},
+		"direction": &graphql.ArgumentConfig{
+			Type:         OrderDirection,
+			DefaultValue: "ASC",
+		},
+	}
+	for name, arg := range extra {
+		args[name] = arg
+	}
+	return args
+}
+
+// pageArgs reads the pagination args of a connection field
+func pageArgs(p graphql.ResolveParams) (postgres.Page, error) {
+	var page postgres.Page
+	page.First, _ = p.Args["first"].(int)
+	page.OrderBy, _ = p.Args["orderBy"].(string)
+	page.Desc = p.Args["direction"] == "DESC"
+	if after, ok := p.Args["after"].(string); ok && after != "" {
+		cursor, err := decodeCursor(after)
+		if err != nil {
+			return page, err
+		}
+		page.After = &cursor
+	}
+	return page, nil
+}
+
+// newConnectionResult builds the connection for a page of n nodes, node
+// returns the i-th node along with its cursor
+func newConnectionResult(page postgres.Page, n int, hasNextPage bool, totalCount int, node func(i int) (interface{}, postgres.Cursor)) connection {
+	c := connection{
+		Edges:      make([]edge, 0, n),
+		TotalCount: totalCount,
+		PageInfo: pageInfo{
+			HasNextPage:     hasNextPage,
+			HasPreviousPage: page.After != nil,
+		},
+	}
+	for i := 0; i < n; i++ {
+		value, cursor := node(i)
+		c.Edges = append(c.Edges, edge{Cursor: encodeCursor(cursor), Node: value})
+	}
+	if n > 0 {
+		c.PageInfo.StartCursor = c.Edges[0].Cursor
+		c.PageInfo.EndCursor = c.Edges[n-1].Cursor
+	}
+	return c
+}
+
+// encodeCursor returns the opaque string form of a cursor
+func encodeCursor(cursor postgres.Cursor) string {
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor parses a cursor returned by encodeCursor
+func decodeCursor(s string) (postgres.Cursor, error) {
+	var cursor postgres.Cursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(b, &cursor)
+	}
+	if err != nil {
+		return cursor, errors.New("invalid cursor")
+	}
+	return cursor, nil
+}
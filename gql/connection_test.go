@@ -0,0 +1,55 @@
+package gql
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"go-graphql-cloud-api/postgres"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := uuid.Must(uuid.FromString("6ba7b810-9dad-11d1-80b4-00c04fd430c8"))
+	tests := []struct {
+		name   string
+		cursor postgres.Cursor
+	}{
+		{"name", postgres.Cursor{OrderBy: "name", Value: "Coffee", ID: id}},
+		{"time", postgres.Cursor{OrderBy: "created_at", Value: "2019-03-14T15:09:26.123456Z", ID: id}},
+		{"unicode and quotes", postgres.Cursor{OrderBy: "name", Value: `咖啡 "special" /+`, ID: id}},
+		{"empty value", postgres.Cursor{OrderBy: "code", ID: id}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := encodeCursor(tt.cursor)
+			got, err := decodeCursor(s)
+			if err != nil {
+				t.Fatalf("decodeCursor(%q) error = %v", s, err)
+			}
+			if got != tt.cursor {
+				t.Errorf("decodeCursor(encodeCursor(%+v)) = %+v", tt.cursor, got)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "not a cursor!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"o":"name","v":"a","id":"6ba7b810-9dad-11d1-80b4-00c04fd430c8"}`))},
+		{"not JSON", base64.RawURLEncoding.EncodeToString([]byte("name,Coffee"))},
+		{"invalid id", base64.RawURLEncoding.EncodeToString([]byte(`{"o":"name","v":"a","id":"nope"}`))},
+		{"wrong types", base64.RawURLEncoding.EncodeToString([]byte(`{"o":1,"v":"a"}`))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := decodeCursor(tt.cursor); err == nil {
+				t.Errorf("decodeCursor(%q) = %+v, want an error", tt.cursor, got)
+			}
+		})
+	}
+}
@@ -3,104 +3,303 @@ package gql
 import (
 	"context"
 	"fmt"
-
 	"log"
 
+	"go-graphql-cloud-api/postgres"
+
 	"github.com/graph-gophers/dataloader"
 	uuid "github.com/satori/go.uuid"
 )
 
-func GetVendorProductsBatchFn(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
-	handleError := func(err error) []*dataloader.Result {
-		var results []*dataloader.Result
-		var result dataloader.Result
-		result.Error = err
-		results = append(results, &result)
-		return results
-	}
-	var vendorIDs []uuid.UUID
-	for _, key := range keys {
+// NewLoaders returns a new set of dataloaders keyed by name
+func NewLoaders() map[string]*dataloader.Loader {
+	var loaders = make(map[string]*dataloader.Loader, 10)
+	loaders["GetVendorProducts"] = dataloader.NewBatchedLoader(GetVendorProductsBatchFn)
+	loaders["GetVendorStores"] = dataloader.NewBatchedLoader(GetVendorStoresBatchFn)
+	loaders["GetVendors"] = dataloader.NewBatchedLoader(GetVendorsBatchFn)
+	loaders["GetProducts"] = dataloader.NewBatchedLoader(GetProductsBatchFn)
+	loaders["GetStores"] = dataloader.NewBatchedLoader(GetStoresBatchFn)
+	loaders["GetStoreInventory"] = dataloader.NewBatchedLoader(GetStoreInventoryBatchFn)
+	loaders["GetOrderItems"] = dataloader.NewBatchedLoader(GetOrderItemsBatchFn)
+	loaders["GetSuppliers"] = dataloader.NewBatchedLoader(GetSuppliersBatchFn)
+	loaders["GetPaymentMethods"] = dataloader.NewBatchedLoader(GetPaymentMethodsBatchFn)
+	loaders["GetStorePaymentMethods"] = dataloader.NewBatchedLoader(GetStorePaymentMethodsBatchFn)
+	return loaders
+}
+
+// newResults returns one result per key, each key that is not a valid UUID
+// already holds its error. The valid IDs are returned for querying the db
+func newResults(keys dataloader.Keys) ([]*dataloader.Result, []uuid.UUID) {
+	results := make([]*dataloader.Result, len(keys))
+	var ids []uuid.UUID
+	for i, key := range keys {
+		results[i] = &dataloader.Result{}
 		k, err := uuid.FromString(key.String())
 		if err != nil {
-			fmt.Println("vendorIDs key error: %v ", err)
+			results[i].Error = fmt.Errorf("invalid id %q: %v", key.String(), err)
+			continue
+		}
+		ids = append(ids, k)
+	}
+	return results, ids
+}
+
+// failResults sets err on every result that has not failed yet
+func failResults(results []*dataloader.Result, err error) []*dataloader.Result {
+	for _, result := range results {
+		if result.Error == nil {
+			result.Error = err
+		}
+	}
+	return results
+}
+
+// fillResults sets the data of every result that has not failed from byID,
+// keys without a row resolve to nil data
+func fillResults(keys dataloader.Keys, results []*dataloader.Result, byID map[uuid.UUID]interface{}) []*dataloader.Result {
+	for i, key := range keys {
+		if results[i].Error != nil {
+			continue
 		}
-		vendorIDs = append(vendorIDs, k)
+		k, _ := uuid.FromString(key.String())
+		if value, ok := byID[k]; ok {
+			results[i].Data = value
+		}
+	}
+	return results
+}
+
+func GetVendorProductsBatchFn(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	results, vendorIDs := newResults(keys)
+	if len(vendorIDs) == 0 {
+		return results
 	}
-	products, err := keys[0].(*ResolverKey).client().resolver().db.GetVendorProducts(vendorIDs)
+	products, err := keys[0].(*ResolverKey).client().resolver().db.GetVendorProducts(ctx, vendorIDs)
 	if err != nil {
-		return handleError(err)
+		return failResults(results, err)
 	}
 
-	var results []*dataloader.Result
-	result := dataloader.Result{
-		Data:  products,
-		Error: nil,
+	// Group the products by their vendor so each key gets its own products
+	grouped := make(map[uuid.UUID][]postgres.Product, len(vendorIDs))
+	for _, product := range products {
+		grouped[product.VendorID.UUID] = append(grouped[product.VendorID.UUID], product)
+	}
+	for i, key := range keys {
+		if results[i].Error != nil {
+			continue
+		}
+		k, _ := uuid.FromString(key.String())
+		results[i].Data = grouped[k]
 	}
-	results = append(results, &result)
 
 	log.Printf("[GetVendorProductsBatchFn] batch size: %d", len(results))
 	return results
 }
 
 func GetVendorStoresBatchFn(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
-	handleError := func(err error) []*dataloader.Result {
-		var results []*dataloader.Result
-		var result dataloader.Result
-		result.Error = err
-		results = append(results, &result)
+	results, vendorIDs := newResults(keys)
+	if len(vendorIDs) == 0 {
 		return results
 	}
-	var vendorIDs []uuid.UUID
-	for _, key := range keys {
-		k, err := uuid.FromString(key.String())
-		if err != nil {
-			fmt.Println("vendorIDs key error: %v ", err)
-		}
-		vendorIDs = append(vendorIDs, k)
-	}
-	stores, err := keys[0].(*ResolverKey).client().resolver().db.GetVendorStores(vendorIDs)
+	stores, err := keys[0].(*ResolverKey).client().resolver().db.GetVendorStores(ctx, vendorIDs)
 	if err != nil {
-		return handleError(err)
+		return failResults(results, err)
 	}
 
-	var results []*dataloader.Result
-	result := dataloader.Result{
-		Data:  stores,
-		Error: nil,
+	// Group the stores by their vendor so each key gets its own stores
+	grouped := make(map[uuid.UUID][]postgres.Store, len(vendorIDs))
+	for _, store := range stores {
+		grouped[store.VendorID.UUID] = append(grouped[store.VendorID.UUID], store)
+	}
+	for i, key := range keys {
+		if results[i].Error != nil {
+			continue
+		}
+		k, _ := uuid.FromString(key.String())
+		results[i].Data = grouped[k]
 	}
-	results = append(results, &result)
 
 	log.Printf("[GetVendorStoresBatchFn] batch size: %d", len(results))
 	return results
 }
 
 func GetVendorsBatchFn(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
-	handleError := func(err error) []*dataloader.Result {
-		var results []*dataloader.Result
-		var result dataloader.Result
-		result.Error = err
-		results = append(results, &result)
+	results, vendorIDs := newResults(keys)
+	if len(vendorIDs) == 0 {
 		return results
 	}
-	var vendorIDs []uuid.UUID
-	for _, key := range keys {
-		k, err := uuid.FromString(key.String())
-		if err != nil {
-			fmt.Println("vendorIDs key error: %v ", err)
+	vendors, err := keys[0].(*ResolverKey).client().resolver().db.GetVendors(ctx, vendorIDs)
+	if err != nil {
+		return failResults(results, err)
+	}
+
+	byID := make(map[uuid.UUID]interface{}, len(vendors))
+	for _, vendor := range vendors {
+		byID[vendor.ID] = vendor
+	}
+	fillResults(keys, results, byID)
+
+	log.Printf("[GetVendorsBatchFn] batch size: %d", len(results))
+	return results
+}
+
+func GetProductsBatchFn(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	results, productIDs := newResults(keys)
+	if len(productIDs) == 0 {
+		return results
+	}
+	products, err := keys[0].(*ResolverKey).client().resolver().db.GetProducts(ctx, productIDs)
+	if err != nil {
+		return failResults(results, err)
+	}
+
+	byID := make(map[uuid.UUID]interface{}, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+	fillResults(keys, results, byID)
+
+	log.Printf("[GetProductsBatchFn] batch size: %d", len(results))
+	return results
+}
+
+func GetStoresBatchFn(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	results, storeIDs := newResults(keys)
+	if len(storeIDs) == 0 {
+		return results
+	}
+	stores, err := keys[0].(*ResolverKey).client().resolver().db.GetStores(ctx, storeIDs)
+	if err != nil {
+		return failResults(results, err)
+	}
+
+	byID := make(map[uuid.UUID]interface{}, len(stores))
+	for _, store := range stores {
+		byID[store.ID] = store
+	}
+	fillResults(keys, results, byID)
+
+	log.Printf("[GetStoresBatchFn] batch size: %d", len(results))
+	return results
+}
+
+func GetStoreInventoryBatchFn(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	results, storeIDs := newResults(keys)
+	if len(storeIDs) == 0 {
+		return results
+	}
+	slots, err := keys[0].(*ResolverKey).client().resolver().db.GetStoreInventory(ctx, storeIDs)
+	if err != nil {
+		return failResults(results, err)
+	}
+
+	// Group the slots by their store so each key gets its own slots
+	grouped := make(map[uuid.UUID][]postgres.InventorySlot, len(storeIDs))
+	for _, slot := range slots {
+		grouped[slot.StoreID] = append(grouped[slot.StoreID], slot)
+	}
+	for i, key := range keys {
+		if results[i].Error != nil {
+			continue
 		}
-		vendorIDs = append(vendorIDs, k)
+		k, _ := uuid.FromString(key.String())
+		results[i].Data = grouped[k]
+	}
+
+	log.Printf("[GetStoreInventoryBatchFn] batch size: %d", len(results))
+	return results
+}
+
+func GetOrderItemsBatchFn(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	results, orderIDs := newResults(keys)
+	if len(orderIDs) == 0 {
+		return results
 	}
-	vendors, err := keys[0].(*ResolverKey).client().resolver().db.GetVendors(vendorIDs)
+	items, err := keys[0].(*ResolverKey).client().resolver().db.GetOrderItems(ctx, orderIDs)
 	if err != nil {
-		return handleError(err)
+		return failResults(results, err)
 	}
 
-	var results []*dataloader.Result
-	result := dataloader.Result{
-		Data:  vendors,
-		Error: nil,
+	// Group the items by their order so each key gets its own items
+	grouped := make(map[uuid.UUID][]postgres.OrderItem, len(orderIDs))
+	for _, item := range items {
+		grouped[item.OrderID] = append(grouped[item.OrderID], item)
 	}
-	results = append(results, &result)
-	log.Printf("[GetVendorsBatchFn] batch size: %d", len(results))
+	for i, key := range keys {
+		if results[i].Error != nil {
+			continue
+		}
+		k, _ := uuid.FromString(key.String())
+		results[i].Data = grouped[k]
+	}
+
+	log.Printf("[GetOrderItemsBatchFn] batch size: %d", len(results))
+	return results
+}
+
+func GetSuppliersBatchFn(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	results, supplierIDs := newResults(keys)
+	if len(supplierIDs) == 0 {
+		return results
+	}
+	suppliers, err := keys[0].(*ResolverKey).client().resolver().db.GetSuppliers(ctx, supplierIDs)
+	if err != nil {
+		return failResults(results, err)
+	}
+
+	byID := make(map[uuid.UUID]interface{}, len(suppliers))
+	for _, supplier := range suppliers {
+		byID[supplier.ID] = supplier
+	}
+	fillResults(keys, results, byID)
+
+	log.Printf("[GetSuppliersBatchFn] batch size: %d", len(results))
+	return results
+}
+
+func GetPaymentMethodsBatchFn(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	results, paymentMethodIDs := newResults(keys)
+	if len(paymentMethodIDs) == 0 {
+		return results
+	}
+	paymentMethods, err := keys[0].(*ResolverKey).client().resolver().db.GetPaymentMethods(ctx, paymentMethodIDs)
+	if err != nil {
+		return failResults(results, err)
+	}
+
+	byID := make(map[uuid.UUID]interface{}, len(paymentMethods))
+	for _, paymentMethod := range paymentMethods {
+		byID[paymentMethod.ID] = paymentMethod
+	}
+	fillResults(keys, results, byID)
+
+	log.Printf("[GetPaymentMethodsBatchFn] batch size: %d", len(results))
+	return results
+}
+
+func GetStorePaymentMethodsBatchFn(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	results, storeIDs := newResults(keys)
+	if len(storeIDs) == 0 {
+		return results
+	}
+	paymentMethods, err := keys[0].(*ResolverKey).client().resolver().db.GetStorePaymentMethods(ctx, storeIDs)
+	if err != nil {
+		return failResults(results, err)
+	}
+
+	// Group the payment methods by their store so each key gets its own payment methods
+	grouped := make(map[uuid.UUID][]postgres.PaymentMethod, len(storeIDs))
+	for _, paymentMethod := range paymentMethods {
+		grouped[paymentMethod.StoreID] = append(grouped[paymentMethod.StoreID], paymentMethod.PaymentMethod)
+	}
+	for i, key := range keys {
+		if results[i].Error != nil {
+			continue
+		}
+		k, _ := uuid.FromString(key.String())
+		results[i].Data = grouped[k]
+	}
+
+	log.Printf("[GetStorePaymentMethodsBatchFn] batch size: %d", len(results))
 	return results
 }
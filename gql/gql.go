@@ -9,12 +9,21 @@ import (
 	"github.com/graphql-go/graphql"
 )
 
+// Request is a graphql operation along with the values of its variables
+type Request struct {
+	Query         string
+	Variables     map[string]interface{}
+	OperationName string
+}
+
 // ExecuteQuery runs our graphql queries
-func ExecuteQuery(query string, schema graphql.Schema, ctx context.Context) *graphql.Result {
+func ExecuteQuery(request Request, schema graphql.Schema, ctx context.Context) *graphql.Result {
 	result := graphql.Do(graphql.Params{
-		Schema:        schema,
-		RequestString: query,
-		Context:       ctx,
+		Schema:         schema,
+		RequestString:  request.Query,
+		VariableValues: request.Variables,
+		OperationName:  request.OperationName,
+		Context:        ctx,
 	})
 
 	b, err := json.Marshal(result)
@@ -0,0 +1,192 @@
+package gql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go-graphql-cloud-api/postgres"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Locales configures how LanguageJson values are localized
+type Locales struct {
+	// Supported are the language tags texts may be written in and searched
+	// by, any well formed tag is accepted when it is empty
+	Supported []string
+	// Fallback is the order languages are tried in when neither the lang
+	// argument nor the request's Accept-Language has a translation
+	Fallback []string
+}
+
+// langPattern matches the form of a BCP 47 language tag such as en, zh-HK or zh-Hant-HK
+var langPattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+// canonicalLang validates the form of a language tag and normalizes its
+// case, e.g. ZH-hant-hk becomes zh-Hant-HK
+func canonicalLang(tag string) (string, error) {
+	if !langPattern.MatchString(tag) {
+		return "", fmt.Errorf("invalid language tag %q", tag)
+	}
+	subtags := strings.Split(tag, "-")
+	subtags[0] = strings.ToLower(subtags[0])
+	for i, subtag := range subtags[1:] {
+		switch len(subtag) {
+		case 2:
+			subtags[i+1] = strings.ToUpper(subtag)
+		case 4:
+			subtags[i+1] = strings.ToUpper(subtag[:1]) + strings.ToLower(subtag[1:])
+		default:
+			subtags[i+1] = strings.ToLower(subtag)
+		}
+	}
+	return strings.Join(subtags, "-"), nil
+}
+
+// check returns the canonical form of tag, or an error if it is not supported
+func (l Locales) check(tag string) (string, error) {
+	lang, err := canonicalLang(tag)
+	if err != nil || len(l.Supported) == 0 {
+		return lang, err
+	}
+	for _, supported := range l.Supported {
+		if strings.EqualFold(supported, lang) {
+			return lang, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported language %q", tag)
+}
+
+// languageJson converts LanguageJsonArgs into a postgres.LanguageJson,
+// entries take precedence over the en and zh shorthands
+func (l Locales) languageJson(args map[string]interface{}) (postgres.LanguageJson, error) {
+	lj := postgres.LanguageJson{}
+	set := func(tag string, text string) error {
+		lang, err := l.check(tag)
+		if err != nil {
+			return err
+		}
+		lj[lang] = text
+		return nil
+	}
+	for _, tag := range []string{"en", "zh"} {
+		if text, ok := args[tag].(string); ok {
+			if err := set(tag, text); err != nil {
+				return nil, err
+			}
+		}
+	}
+	entries, _ := args["entries"].([]interface{})
+	for _, entry := range entries {
+		e, _ := entry.(map[string]interface{})
+		tag, _ := e["lang"].(string)
+		text, _ := e["text"].(string)
+		if err := set(tag, text); err != nil {
+			return nil, err
+		}
+	}
+	return lj, nil
+}
+
+// localize returns the text of lj in the first language that has one, trying
+// the lang argument, the request languages and then the fallback order
+func localize(lj postgres.LanguageJson, lang string, requested []string, fallback []string) (string, bool) {
+	var langs []string
+	if lang != "" {
+		langs = append(langs, lang)
+	}
+	langs = append(langs, requested...)
+	langs = append(langs, fallback...)
+	for _, l := range langs {
+		// Try a regional tag like zh-HK before its base language
+		for _, candidate := range []string{l, strings.SplitN(l, "-", 2)[0]} {
+			if text, ok := lj.Get(candidate); ok {
+				return text, true
+			}
+		}
+	}
+	return "", false
+}
+
+// localizedField returns a String field resolving a LanguageJson of the
+// source to a single language, selected by its optional lang argument
+func localizedField(description string, get func(source interface{}) postgres.LanguageJson) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.String,
+		Description: description,
+		Args: graphql.FieldConfigArgument{
+			"lang": &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			var (
+				v            = p.Context.Value
+				c            = v("client").(*Client)
+				requested, _ = v("langs").([]string)
+				lang, _      = p.Args["lang"].(string)
+			)
+			text, ok := localize(get(p.Source), lang, requested, c.resolver().config.Locales.Fallback)
+			if !ok {
+				return nil, nil
+			}
+			return text, nil
+		},
+	}
+}
+
+// languageText is the source of a LanguageText object
+type languageText struct {
+	Lang string `json:"lang"`
+	Text string `json:"text"`
+}
+
+// LanguageText describes a graphql object containing the text of a single language
+var LanguageText = graphql.NewObject(graphql.ObjectConfig{
+	Name: "LanguageText",
+	Fields: graphql.Fields{
+		"lang": &graphql.Field{
+			Type: graphql.String,
+		},
+		"text": &graphql.Field{
+			Type: graphql.String,
+		},
+	},
+})
+
+// languageField returns a field resolving the text of a single fixed language
+func languageField(lang string) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.String,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			if text, ok := p.Source.(postgres.LanguageJson).Get(lang); ok {
+				return text, nil
+			}
+			return nil, nil
+		},
+	}
+}
+
+// LanguageJson describes a graphql object containing a text in every language it is available in
+var LanguageJson = graphql.NewObject(graphql.ObjectConfig{
+	Name: "LanguageJson",
+	Fields: graphql.Fields{
+		"en": languageField("en"),
+		"zh": languageField("zh"),
+		"entries": &graphql.Field{
+			Type: graphql.NewList(LanguageText),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				lj := p.Source.(postgres.LanguageJson)
+				var entries []languageText
+				for _, lang := range lj.Langs() {
+					entries = append(entries, languageText{Lang: lang, Text: lj[lang]})
+				}
+				return entries, nil
+			},
+		},
+		"translate": localizedField("The text in the given language or the best fallback", func(source interface{}) postgres.LanguageJson {
+			return source.(postgres.LanguageJson)
+		}),
+	},
+})
@@ -0,0 +1,102 @@
+package gql
+
+import (
+	"testing"
+
+	"go-graphql-cloud-api/postgres"
+)
+
+func TestCanonicalLang(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    string
+		wantErr bool
+	}{
+		{"en", "en", false},
+		{"EN", "en", false},
+		{"zh-hk", "zh-HK", false},
+		{"ZH-hant-hk", "zh-Hant-HK", false},
+		{"yue", "yue", false},
+		{"es-419", "es-419", false},
+		{"sl-rozaj", "sl-rozaj", false},
+		{"", "", true},
+		{"e", "", true},
+		{"english", "", true},
+		{"en_US", "", true},
+		{"en-", "", true},
+		{"en-abcdefghi", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, err := canonicalLang(tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("canonicalLang(%q) error = %v, wantErr %v", tt.tag, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("canonicalLang(%q) = %q, want %q", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalesCheck(t *testing.T) {
+	supported := Locales{Supported: []string{"en", "zh-HK"}}
+	tests := []struct {
+		name    string
+		locales Locales
+		tag     string
+		want    string
+		wantErr bool
+	}{
+		{"supported", supported, "en", "en", false},
+		{"supported in another case", supported, "ZH-hk", "zh-HK", false},
+		{"unsupported", supported, "fr", "", true},
+		{"base language of a supported region", supported, "zh", "", true},
+		{"any tag without supported languages", Locales{}, "FR-ca", "fr-CA", false},
+		{"malformed", Locales{}, "en_US", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.locales.check(tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("check(%q) error = %v, wantErr %v", tt.tag, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("check(%q) = %q, want %q", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	lj := postgres.LanguageJson{"en": "Coffee", "zh": "咖啡", "zh-HK": "咖啡（港）", "fr": ""}
+	fallback := []string{"en", "zh"}
+	tests := []struct {
+		name      string
+		lj        postgres.LanguageJson
+		lang      string
+		requested []string
+		fallback  []string
+		want      string
+		wantOK    bool
+	}{
+		{"lang argument", lj, "zh", []string{"en"}, fallback, "咖啡", true},
+		{"lang argument in another case", lj, "ZH-hk", nil, fallback, "咖啡（港）", true},
+		{"requested language", lj, "", []string{"zh-HK", "en"}, fallback, "咖啡（港）", true},
+		{"base language of a requested region", lj, "", []string{"zh-TW"}, fallback, "咖啡", true},
+		{"next requested language", lj, "", []string{"de", "zh"}, fallback, "咖啡", true},
+		{"missing lang argument falls back to the requested languages", lj, "de", []string{"zh"}, fallback, "咖啡", true},
+		{"fallback order", lj, "", []string{"de"}, []string{"zh", "en"}, "咖啡", true},
+		{"empty text falls back", lj, "fr", nil, fallback, "Coffee", true},
+		{"no language has a text", lj, "de", []string{"ja"}, []string{"ko"}, "", false},
+		{"no texts", postgres.LanguageJson{}, "en", nil, fallback, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := localize(tt.lj, tt.lang, tt.requested, tt.fallback)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("localize(%v, %q, %q, %q) = %q, %v, want %q, %v", tt.lj, tt.lang, tt.requested, tt.fallback, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
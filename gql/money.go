@@ -0,0 +1,36 @@
+package gql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"go-graphql-cloud-api/scalar"
+
+	"github.com/graphql-go/graphql"
+)
+
+// moneyField returns a Money field resolving an amount of the source, stored
+// in the smallest unit of the configured currency
+func moneyField(description string, get func(source interface{}) int64) *graphql.Field {
+	return &graphql.Field{
+		Type:        scalar.MoneyScalar,
+		Description: description,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			c := p.Context.Value("client").(*Client)
+			return scalar.Money{Amount: get(p.Source), Currency: c.resolver().config.Currency}, nil
+		},
+	}
+}
+
+// nullMoney converts an optional Money arg into a sql.NullInt64 amount, the
+// amount must be in the configured currency as amounts are stored without one
+func (r *Resolver) nullMoney(name string, value interface{}) (sql.NullInt64, error) {
+	m, ok := value.(scalar.Money)
+	if !ok {
+		return sql.NullInt64{}, nil
+	}
+	if m.Currency != r.config.Currency {
+		return sql.NullInt64{}, fmt.Errorf("%s must be in %s, not %s", name, r.config.Currency, m.Currency)
+	}
+	return sql.NullInt64{Int64: m.Amount, Valid: true}, nil
+}
@@ -0,0 +1,485 @@
+package gql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"go-graphql-cloud-api/postgres"
+
+	"github.com/graph-gophers/dataloader"
+	"github.com/graphql-go/graphql"
+	uuid "github.com/satori/go.uuid"
+)
+
+// CreateVendorResolver inserts a new vendor
+func (r *Resolver) CreateVendorResolver(p graphql.ResolveParams) (interface{}, error) {
+	values, err := vendorValues(p.Args["vendor"].(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	if err := requireValues(values, "name"); err != nil {
+		return nil, err
+	}
+	return r.db.CreateVendor(p.Context, values)
+}
+
+// EditVendorResolver applies the supplied vendor fields to the vendor with the given id
+func (r *Resolver) EditVendorResolver(p graphql.ResolveParams) (interface{}, error) {
+	id, err := parseID("id", p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	values, err := vendorValues(p.Args["vendor"].(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no vendor fields to update")
+	}
+	vendor, err := r.db.EditVendor(p.Context, id, values)
+	if err != nil {
+		return nil, err
+	}
+	// Replace any cached copy so later reads in this request see the update
+	primeLoader(p.Context, "GetVendors", id, vendor)
+	return vendor, nil
+}
+
+// DeleteVendorResolver deletes a vendor and returns it
+func (r *Resolver) DeleteVendorResolver(p graphql.ResolveParams) (interface{}, error) {
+	id, err := parseID("id", p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	vendor, err := r.db.DeleteVendor(p.Context, id)
+	if err != nil {
+		return nil, err
+	}
+	clearLoader(p.Context, "GetVendors", id)
+	return vendor, nil
+}
+
+// UpsertVendorTreeResolver creates or updates a vendor along with its products
+// and stores in one transaction. Rows with an id are updated, others are created
+func (r *Resolver) UpsertVendorTreeResolver(p graphql.ResolveParams) (interface{}, error) {
+	vendorArgs := p.Args["vendor"].(map[string]interface{})
+	vendor, err := upsertValues(vendorArgs, vendorValues)
+	if err != nil {
+		return nil, err
+	}
+	if !vendor.ID.Valid {
+		if err := requireValues(vendor.Values, "name"); err != nil {
+			return nil, err
+		}
+	}
+
+	products, err := upsertList("products", vendorArgs["products"], r.productValues)
+	if err != nil {
+		return nil, err
+	}
+	stores, err := upsertList("stores", vendorArgs["stores"], storeValues)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := r.db.UpsertVendorTree(p.Context, vendor, products, stores)
+	if err != nil {
+		return nil, err
+	}
+	primeLoader(p.Context, "GetVendors", v.ID, v)
+	clearLoader(p.Context, "GetVendorProducts", v.ID)
+	clearLoader(p.Context, "GetVendorStores", v.ID)
+	return v, nil
+}
+
+// upsertValues converts an input object with an optional id into a postgres.Upsert
+func upsertValues(args map[string]interface{}, convert func(map[string]interface{}) (map[string]interface{}, error)) (postgres.Upsert, error) {
+	var upsert postgres.Upsert
+	if value, ok := args["id"]; ok && value != nil {
+		id, err := parseID("id", value)
+		if err != nil {
+			return upsert, err
+		}
+		upsert.ID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	values, err := convert(args)
+	if err != nil {
+		return upsert, err
+	}
+	upsert.Values = values
+	return upsert, nil
+}
+
+// upsertList converts a list of input objects into postgres.Upserts
+func upsertList(name string, list interface{}, convert func(map[string]interface{}) (map[string]interface{}, error)) ([]postgres.Upsert, error) {
+	items, _ := list.([]interface{})
+	upserts := make([]postgres.Upsert, 0, len(items))
+	for i, item := range items {
+		args, _ := item.(map[string]interface{})
+		upsert, err := upsertValues(args, convert)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %v", name, i, err)
+		}
+		upserts = append(upserts, upsert)
+	}
+	return upserts, nil
+}
+
+// CreateProductResolver inserts a new product for a vendor
+func (r *Resolver) CreateProductResolver(p graphql.ResolveParams) (interface{}, error) {
+	values, err := r.productValues(p.Args["product"].(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	if err := requireValues(values, "vendor_id"); err != nil {
+		return nil, err
+	}
+	product, err := r.db.CreateProduct(p.Context, values)
+	if err != nil {
+		return nil, err
+	}
+	clearLoader(p.Context, "GetVendorProducts", product.VendorID.UUID)
+	return product, nil
+}
+
+// EditProductResolver applies the supplied product fields to the product with the given id
+func (r *Resolver) EditProductResolver(p graphql.ResolveParams) (interface{}, error) {
+	id, err := parseID("id", p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	values, err := r.productValues(p.Args["product"].(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no product fields to update")
+	}
+	product, err := r.db.EditProduct(p.Context, id, values)
+	if err != nil {
+		return nil, err
+	}
+	primeLoader(p.Context, "GetProducts", id, product)
+	clearLoader(p.Context, "GetVendorProducts", product.VendorID.UUID)
+	return product, nil
+}
+
+// DeleteProductResolver deletes a product and returns it
+func (r *Resolver) DeleteProductResolver(p graphql.ResolveParams) (interface{}, error) {
+	id, err := parseID("id", p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	product, err := r.db.DeleteProduct(p.Context, id)
+	if err != nil {
+		return nil, err
+	}
+	clearLoader(p.Context, "GetProducts", id)
+	clearLoader(p.Context, "GetVendorProducts", product.VendorID.UUID)
+	return product, nil
+}
+
+// CreateStoreResolver inserts a new store for a vendor
+func (r *Resolver) CreateStoreResolver(p graphql.ResolveParams) (interface{}, error) {
+	values, err := storeValues(p.Args["store"].(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	if err := requireValues(values, "vendor_id"); err != nil {
+		return nil, err
+	}
+	store, err := r.db.CreateStore(p.Context, values)
+	if err != nil {
+		return nil, err
+	}
+	clearLoader(p.Context, "GetVendorStores", store.VendorID.UUID)
+	return store, nil
+}
+
+// EditStoreResolver applies the supplied store fields to the store with the given id
+func (r *Resolver) EditStoreResolver(p graphql.ResolveParams) (interface{}, error) {
+	id, err := parseID("id", p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	values, err := storeValues(p.Args["store"].(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no store fields to update")
+	}
+	store, err := r.db.EditStore(p.Context, id, values)
+	if err != nil {
+		return nil, err
+	}
+	primeLoader(p.Context, "GetStores", id, store)
+	clearLoader(p.Context, "GetVendorStores", store.VendorID.UUID)
+	return store, nil
+}
+
+// DeleteStoreResolver deletes a store and returns it
+func (r *Resolver) DeleteStoreResolver(p graphql.ResolveParams) (interface{}, error) {
+	id, err := parseID("id", p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	store, err := r.db.DeleteStore(p.Context, id)
+	if err != nil {
+		return nil, err
+	}
+	clearLoader(p.Context, "GetStores", id)
+	clearLoader(p.Context, "GetVendorStores", store.VendorID.UUID)
+	return store, nil
+}
+
+// RefillStoreResolver records the refill of the slots of a store
+func (r *Resolver) RefillStoreResolver(p graphql.ResolveParams) (interface{}, error) {
+	storeID, err := parseID("store_id", p.Args["store_id"])
+	if err != nil {
+		return nil, err
+	}
+	items, _ := p.Args["slots"].([]interface{})
+	refills := make([]postgres.SlotRefill, len(items))
+	for i, item := range items {
+		args, _ := item.(map[string]interface{})
+		refills[i].Slot = int64(args["slot"].(int))
+		refills[i].Quantity = nullInt(args["quantity"])
+		refills[i].Capacity = nullInt(args["capacity"])
+		if refills[i].Price, err = r.nullMoney("price", args["price"]); err != nil {
+			return nil, fmt.Errorf("slots[%d]: %v", i, err)
+		}
+		if refills[i].ProductID, err = nullID("product_id", args["product_id"]); err != nil {
+			return nil, fmt.Errorf("slots[%d]: %v", i, err)
+		}
+	}
+	store, err := r.db.RefillStore(p.Context, storeID, refills)
+	if err != nil {
+		return nil, err
+	}
+	primeLoader(p.Context, "GetStores", storeID, store)
+	clearLoader(p.Context, "GetStoreInventory", storeID)
+	return store, nil
+}
+
+// SubmitOrdersResolver records a batch of orders buffered by a store, orders
+// the store already submitted are returned as they were recorded
+func (r *Resolver) SubmitOrdersResolver(p graphql.ResolveParams) (interface{}, error) {
+	storeID, err := parseID("store_id", p.Args["store_id"])
+	if err != nil {
+		return nil, err
+	}
+	orders, _ := p.Args["orders"].([]interface{})
+	submissions := make([]postgres.OrderSubmission, len(orders))
+	for i, order := range orders {
+		args, _ := order.(map[string]interface{})
+		submissions[i].ClientOrderID, _ = args["client_order_id"].(string)
+		submissions[i].OrderedAt = nullTime(args["ordered_at"])
+		if submissions[i].PaymentMethodID, err = nullID("payment_method_id", args["payment_method_id"]); err != nil {
+			return nil, fmt.Errorf("orders[%d]: %v", i, err)
+		}
+		items, _ := args["items"].([]interface{})
+		submissions[i].Items = make([]postgres.OrderItemSubmission, len(items))
+		for j, item := range items {
+			itemArgs, _ := item.(map[string]interface{})
+			submission := &submissions[i].Items[j]
+			submission.Quantity = int64(itemArgs["quantity"].(int))
+			submission.Slot = nullInt(itemArgs["slot"])
+			if submission.UnitPrice, err = r.nullMoney("unit_price", itemArgs["unit_price"]); err != nil {
+				return nil, fmt.Errorf("orders[%d].items[%d]: %v", i, j, err)
+			}
+			if submission.ProductID, err = nullID("product_id", itemArgs["product_id"]); err != nil {
+				return nil, fmt.Errorf("orders[%d].items[%d]: %v", i, j, err)
+			}
+		}
+	}
+
+	submitted, err := r.db.SubmitOrders(p.Context, storeID, submissions)
+	if err != nil {
+		return nil, err
+	}
+	clearLoader(p.Context, "GetStores", storeID)
+	clearLoader(p.Context, "GetStoreInventory", storeID)
+	return submitted, nil
+}
+
+// CreatePaymentMethodResolver inserts a new payment method
+func (r *Resolver) CreatePaymentMethodResolver(p graphql.ResolveParams) (interface{}, error) {
+	values := paymentMethodValues(p.Args["payment_method"].(map[string]interface{}))
+	if err := requireValues(values, "code", "name"); err != nil {
+		return nil, err
+	}
+	return r.db.CreatePaymentMethod(p.Context, values)
+}
+
+// EditPaymentMethodResolver applies the supplied payment method fields to the
+// payment method with the given id
+func (r *Resolver) EditPaymentMethodResolver(p graphql.ResolveParams) (interface{}, error) {
+	id, err := parseID("id", p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	values := paymentMethodValues(p.Args["payment_method"].(map[string]interface{}))
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no payment method fields to update")
+	}
+	paymentMethod, err := r.db.EditPaymentMethod(p.Context, id, values)
+	if err != nil {
+		return nil, err
+	}
+	primeLoader(p.Context, "GetPaymentMethods", id, paymentMethod)
+	return paymentMethod, nil
+}
+
+// DeletePaymentMethodResolver deletes a payment method and returns it
+func (r *Resolver) DeletePaymentMethodResolver(p graphql.ResolveParams) (interface{}, error) {
+	id, err := parseID("id", p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	paymentMethod, err := r.db.DeletePaymentMethod(p.Context, id)
+	if err != nil {
+		return nil, err
+	}
+	clearLoader(p.Context, "GetPaymentMethods", id)
+	return paymentMethod, nil
+}
+
+// SetStorePaymentMethodsResolver replaces the payment methods accepted by a store
+func (r *Resolver) SetStorePaymentMethodsResolver(p graphql.ResolveParams) (interface{}, error) {
+	storeID, err := parseID("store_id", p.Args["store_id"])
+	if err != nil {
+		return nil, err
+	}
+	items, _ := p.Args["payment_method_ids"].([]interface{})
+	ids := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		if ids[i], err = parseID("payment_method_ids", item); err != nil {
+			return nil, err
+		}
+	}
+	store, err := r.db.SetStorePaymentMethods(p.Context, storeID, ids)
+	if err != nil {
+		return nil, err
+	}
+	primeLoader(p.Context, "GetStores", storeID, store)
+	clearLoader(p.Context, "GetStorePaymentMethods", storeID)
+	return store, nil
+}
+
+// vendorValues converts VendorArgs into vendor column values
+func vendorValues(args map[string]interface{}) (map[string]interface{}, error) {
+	return columnValues(args, "name", "description"), nil
+}
+
+// productValues converts ProductArgs into product column values. The texts
+// are merged into the languages a product already has on update, and a
+// language whose text is set to "" is removed. The optional data is merged
+// alike, with its keys set to null removed
+func (r *Resolver) productValues(args map[string]interface{}) (map[string]interface{}, error) {
+	values := columnValues(args, "photo", "code", "is_virtual_product", "barcode")
+	if data, ok := args["optional_data"].(map[string]interface{}); ok {
+		values["optional_data"] = postgres.JSONMap(data).Merge()
+	}
+	for _, column := range []string{"descriptions", "brand_names", "names"} {
+		if ljArgs, ok := args[column].(map[string]interface{}); ok {
+			lj, err := r.config.Locales.languageJson(ljArgs)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", column, err)
+			}
+			values[column] = lj.Merge()
+		}
+	}
+	for _, column := range []string{"vendor_id", "supplier_id"} {
+		if value, ok := args[column]; ok && value != nil {
+			id, err := parseID(column, value)
+			if err != nil {
+				return nil, err
+			}
+			values[column] = id
+		}
+	}
+	return values, nil
+}
+
+// storeValues converts StoreArgs into store column values
+func storeValues(args map[string]interface{}) (map[string]interface{}, error) {
+	values := columnValues(args, "mongo_id", "code", "name", "model", "address",
+		"last_online_at", "last_get", "last_refill", "last_reset", "last_sync", "unsubmitted_order_count")
+	if value, ok := args["vendor_id"]; ok && value != nil {
+		id, err := parseID("vendor_id", value)
+		if err != nil {
+			return nil, err
+		}
+		values["vendor_id"] = id
+	}
+	return values, nil
+}
+
+// paymentMethodValues converts PaymentMethodArgs into payment method column values
+func paymentMethodValues(args map[string]interface{}) map[string]interface{} {
+	return columnValues(args, "mongo_id", "code", "name", "module", "module_channel", "order_index")
+}
+
+// nullInt converts an optional Int arg into a sql.NullInt64
+func nullInt(value interface{}) sql.NullInt64 {
+	i, ok := value.(int)
+	return sql.NullInt64{Int64: int64(i), Valid: ok}
+}
+
+// columnValues picks the supplied, non null args out of an input object
+func columnValues(args map[string]interface{}, columns ...string) map[string]interface{} {
+	values := make(map[string]interface{}, len(columns))
+	for _, column := range columns {
+		if value, ok := args[column]; ok && value != nil {
+			values[column] = value
+		}
+	}
+	return values
+}
+
+// requireValues returns an error naming the first column that was not supplied
+func requireValues(values map[string]interface{}, columns ...string) error {
+	for _, column := range columns {
+		if _, ok := values[column]; !ok {
+			return fmt.Errorf("%s is required", column)
+		}
+	}
+	return nil
+}
+
+// parseID parses the UUID given in the named argument
+func parseID(name string, value interface{}) (uuid.UUID, error) {
+	// Args of the UUID scalar are parsed already
+	if id, ok := value.(uuid.UUID); ok {
+		return id, nil
+	}
+	s, _ := value.(string)
+	id, err := uuid.FromString(s)
+	if err != nil {
+		return id, fmt.Errorf("invalid %s %q: %v", name, s, err)
+	}
+	return id, nil
+}
+
+// clearLoader removes the cached result for id from the named loader of this request
+func clearLoader(ctx context.Context, name string, id uuid.UUID) {
+	var (
+		v       = ctx.Value
+		c       = v("client").(*Client)
+		loaders = v("loaders").(map[string]*dataloader.Loader)
+	)
+	loaders[name].Clear(ctx, NewResolverKey(id.String(), c))
+}
+
+// primeLoader replaces the cached result for id in the named loader of this request
+func primeLoader(ctx context.Context, name string, id uuid.UUID, value interface{}) {
+	var (
+		v       = ctx.Value
+		c       = v("client").(*Client)
+		loaders = v("loaders").(map[string]*dataloader.Loader)
+		key     = NewResolverKey(id.String(), c)
+	)
+	loaders[name].Clear(ctx, key).Prime(ctx, key, value)
+}
@@ -4,30 +4,42 @@ import (
 	"context"
 
 	"go-graphql-cloud-api/postgres"
+	"go-graphql-cloud-api/scalar"
 
-	"github.com/graph-gophers/dataloader"
 	"github.com/graphql-go/graphql"
 )
 
 // Root holds a pointer to a graphql object
 type Root struct {
-	Query    *graphql.Object
-	Mutation *graphql.Object
-	Context  *context.Context
+	Query        *graphql.Object
+	Mutation     *graphql.Object
+	Subscription *graphql.Object
+	client       *Client
+	broker       *broker
+}
+
+// NewContext returns a copy of parent holding a fresh set of dataloaders. It is
+// called once per request so batching and caching never outlive a single query
+func (r *Root) NewContext(parent context.Context) context.Context {
+	ctx := context.WithValue(parent, "loaders", NewLoaders())
+	return context.WithValue(ctx, "client", r.client)
+}
+
+// Config holds the settings of our graphql api
+type Config struct {
+	Locales     Locales
+	StoreStatus StoreStatusThresholds
+	// Currency is the ISO 4217 code of the amounts stored in the database
+	Currency string
 }
 
 // NewRoot returns base query type. This is where we add all the base queries
-func NewRoot(db *postgres.Db) *Root {
+func NewRoot(db *postgres.Db, config Config) *Root {
 	// Create a resolver holding our databse. Resolver can be found in resolvers.go
-	resolver := Resolver{db: db}
-	// Dataloader
-	var loaders = make(map[string]*dataloader.Loader, 1)
+	resolver := Resolver{db: db, config: config}
+	// Dataloaders are created per request in NewContext and reach our
+	// database through the client
 	var client = Client{Resolver: &resolver}
-	loaders["GetVendorProducts"] = dataloader.NewBatchedLoader(GetVendorProductsBatchFn)
-	loaders["GetVendorStores"] = dataloader.NewBatchedLoader(GetVendorStoresBatchFn)
-	loaders["GetVendors"] = dataloader.NewBatchedLoader(GetVendorsBatchFn)
-	ctx := context.WithValue(context.Background(), "loaders", loaders)
-	ctx = context.WithValue(ctx, "client", &client)
 
 	// Create a new Root that describes our base query set up. In this
 	// example we have a user query that takes one argument called name
@@ -36,15 +48,182 @@ func NewRoot(db *postgres.Db) *Root {
 			graphql.ObjectConfig{
 				Name: "Query",
 				Fields: graphql.Fields{
+					"vendor": &graphql.Field{
+						// Vendor type which can be found in types.go
+						Type: Vendor,
+						Args: graphql.FieldConfigArgument{
+							"id": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.UUIDScalar),
+							},
+						},
+						Resolve: resolver.VendorResolver,
+					},
 					"vendors": &graphql.Field{
-						// Slice of Vendor type which can be found in types.go
-						Type: graphql.NewList(Vendor),
+						// Page of Vendor type which can be found in types.go
+						Type: VendorConnection,
+						Args: connectionArgs(VendorOrderField, graphql.FieldConfigArgument{
+							"filter": &graphql.ArgumentConfig{
+								Type: VendorFilterArgs,
+							},
+						}),
+						Resolve: resolver.VendorsResolver,
+					},
+					"product": &graphql.Field{
+						// Product type which can be found in types.go
+						Type: Product,
 						Args: graphql.FieldConfigArgument{
 							"id": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.UUIDScalar),
+							},
+						},
+						Resolve: resolver.ProductResolver,
+					},
+					"productByBarcode": &graphql.Field{
+						// Product type which can be found in types.go
+						Type: Product,
+						Args: graphql.FieldConfigArgument{
+							"barcode": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(graphql.String),
+							},
+						},
+						Resolve: resolver.ProductByBarcodeResolver,
+					},
+					"products": &graphql.Field{
+						// Page of Product type which can be found in types.go
+						Type: ProductConnection,
+						Args: connectionArgs(TimestampOrderField, graphql.FieldConfigArgument{
+							"filter": &graphql.ArgumentConfig{
+								Type: ProductFilterArgs,
+							},
+						}),
+						Resolve: resolver.ProductsResolver,
+					},
+					"searchProducts": &graphql.Field{
+						// Slice of ProductSearchResult type which can be found in types.go
+						Type: graphql.NewList(ProductSearchResult),
+						Args: graphql.FieldConfigArgument{
+							"text": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(graphql.String),
+							},
+							"lang": &graphql.ArgumentConfig{
 								Type: graphql.String,
 							},
+							"first": &graphql.ArgumentConfig{
+								Type:         graphql.Int,
+								DefaultValue: postgres.DefaultPageSize,
+							},
 						},
-						Resolve: resolver.VendorResolver,
+						Resolve: resolver.SearchProductsResolver,
+					},
+					"supplier": &graphql.Field{
+						// Supplier type which can be found in types.go
+						Type: Supplier,
+						Args: graphql.FieldConfigArgument{
+							"id": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.UUIDScalar),
+							},
+						},
+						Resolve: resolver.SupplierResolver,
+					},
+					"suppliers": &graphql.Field{
+						// Page of Supplier type which can be found in types.go
+						Type: SupplierConnection,
+						Args: connectionArgs(SupplierOrderField, graphql.FieldConfigArgument{
+							"filter": &graphql.ArgumentConfig{
+								Type: SupplierFilterArgs,
+							},
+						}),
+						Resolve: resolver.SuppliersResolver,
+					},
+					"store": &graphql.Field{
+						// Store type which can be found in types.go
+						Type: Store,
+						Args: graphql.FieldConfigArgument{
+							"id": &graphql.ArgumentConfig{
+								Type: scalar.UUIDScalar,
+							},
+							"code": &graphql.ArgumentConfig{
+								Type: graphql.String,
+							},
+						},
+						Resolve: resolver.StoreResolver,
+					},
+					"staleStores": &graphql.Field{
+						// Slice of Store type which can be found in types.go
+						Type: graphql.NewList(Store),
+						Args: graphql.FieldConfigArgument{
+							"vendorId": &graphql.ArgumentConfig{
+								Type: scalar.UUIDScalar,
+							},
+							"olderThan": &graphql.ArgumentConfig{
+								Type:         graphql.String,
+								Description:  "A duration such as 90m or 24h",
+								DefaultValue: "24h",
+							},
+						},
+						Resolve: resolver.StaleStoresResolver,
+					},
+					"lowStockSlots": &graphql.Field{
+						// Slice of InventorySlot type which can be found in types.go
+						Type: graphql.NewList(InventorySlot),
+						Args: graphql.FieldConfigArgument{
+							"vendor_id": &graphql.ArgumentConfig{
+								Type: scalar.UUIDScalar,
+							},
+							"store_id": &graphql.ArgumentConfig{
+								Type: scalar.UUIDScalar,
+							},
+							"ratio": &graphql.ArgumentConfig{
+								Type:         graphql.Float,
+								Description:  "The share of its capacity a slot is filled at or below, between 0 and 1",
+								DefaultValue: 0.25,
+							},
+						},
+						Resolve: resolver.LowStockSlotsResolver,
+					},
+					"salesReport": &graphql.Field{
+						// SalesReport type which can be found in types.go
+						Type: SalesReport,
+						Args: graphql.FieldConfigArgument{
+							"vendorId": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.UUIDScalar),
+							},
+							"from": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.SpecialDateScalar),
+							},
+							"to": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.SpecialDateScalar),
+							},
+							"groupBy": &graphql.ArgumentConfig{
+								Type: graphql.NewList(graphql.NewNonNull(SalesReportGroup)),
+							},
+						},
+						Resolve: resolver.SalesReportResolver,
+					},
+					"paymentMethod": &graphql.Field{
+						// PaymentMethod type which can be found in types.go
+						Type: PaymentMethod,
+						Args: graphql.FieldConfigArgument{
+							"id": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.UUIDScalar),
+							},
+						},
+						Resolve: resolver.PaymentMethodResolver,
+					},
+					"paymentMethods": &graphql.Field{
+						// Slice of PaymentMethod type which can be found in types.go
+						Type:    graphql.NewList(PaymentMethod),
+						Resolve: resolver.PaymentMethodsResolver,
+					},
+					"stores": &graphql.Field{
+						// Page of Store type which can be found in types.go
+						Type: StoreConnection,
+						Args: connectionArgs(TimestampOrderField, graphql.FieldConfigArgument{
+							"filter": &graphql.ArgumentConfig{
+								Type: StoreFilterArgs,
+							},
+						}),
+						Resolve: resolver.StoresResolver,
 					},
 				},
 			},
@@ -53,20 +232,222 @@ func NewRoot(db *postgres.Db) *Root {
 			graphql.ObjectConfig{
 				Name: "Mutation",
 				Fields: graphql.Fields{
+					"createVendor": &graphql.Field{
+						// Created Vendor type which can be found in types.go
+						Type: Vendor,
+						Args: graphql.FieldConfigArgument{
+							"vendor": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(VendorInput),
+							},
+						},
+						Resolve: resolver.CreateVendorResolver,
+					},
 					"editVendor": &graphql.Field{
-						// Slice of Vendor type which can be found in types.go
+						// Updated Vendor type which can be found in types.go
 						Type: Vendor,
 						Args: graphql.FieldConfigArgument{
+							"id": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.UUIDScalar),
+							},
 							"vendor": &graphql.ArgumentConfig{
-								Type: graphql.NewInputObject(VendorArgs),
+								Type: graphql.NewNonNull(VendorInput),
 							},
 						},
 						Resolve: resolver.EditVendorResolver,
 					},
+					"deleteVendor": &graphql.Field{
+						// Deleted Vendor type which can be found in types.go
+						Type: Vendor,
+						Args: graphql.FieldConfigArgument{
+							"id": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.UUIDScalar),
+							},
+						},
+						Resolve: resolver.DeleteVendorResolver,
+					},
+					"upsertVendorTree": &graphql.Field{
+						// Vendor type written together with its products and stores
+						Type: Vendor,
+						Args: graphql.FieldConfigArgument{
+							"vendor": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(VendorInput),
+							},
+						},
+						Resolve: resolver.UpsertVendorTreeResolver,
+					},
+					"createProduct": &graphql.Field{
+						// Created Product type which can be found in types.go
+						Type: Product,
+						Args: graphql.FieldConfigArgument{
+							"product": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(ProductInput),
+							},
+						},
+						Resolve: resolver.CreateProductResolver,
+					},
+					"updateProduct": &graphql.Field{
+						// Updated Product type which can be found in types.go
+						Type: Product,
+						Args: graphql.FieldConfigArgument{
+							"id": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.UUIDScalar),
+							},
+							"product": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(ProductInput),
+							},
+						},
+						Resolve: resolver.EditProductResolver,
+					},
+					"deleteProduct": &graphql.Field{
+						// Deleted Product type which can be found in types.go
+						Type: Product,
+						Args: graphql.FieldConfigArgument{
+							"id": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.UUIDScalar),
+							},
+						},
+						Resolve: resolver.DeleteProductResolver,
+					},
+					"createStore": &graphql.Field{
+						// Created Store type which can be found in types.go
+						Type: Store,
+						Args: graphql.FieldConfigArgument{
+							"store": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(StoreInput),
+							},
+						},
+						Resolve: resolver.CreateStoreResolver,
+					},
+					"updateStore": &graphql.Field{
+						// Updated Store type which can be found in types.go
+						Type: Store,
+						Args: graphql.FieldConfigArgument{
+							"id": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.UUIDScalar),
+							},
+							"store": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(StoreInput),
+							},
+						},
+						Resolve: resolver.EditStoreResolver,
+					},
+					"deleteStore": &graphql.Field{
+						// Deleted Store type which can be found in types.go
+						Type: Store,
+						Args: graphql.FieldConfigArgument{
+							"id": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.UUIDScalar),
+							},
+						},
+						Resolve: resolver.DeleteStoreResolver,
+					},
+					"refillStore": &graphql.Field{
+						// Refilled Store type which can be found in types.go
+						Type: Store,
+						Args: graphql.FieldConfigArgument{
+							"store_id": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.UUIDScalar),
+							},
+							"slots": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(SlotRefillArgs))),
+							},
+						},
+						Resolve: resolver.RefillStoreResolver,
+					},
+					"submitOrders": &graphql.Field{
+						// Slice of submitted Order type which can be found in types.go
+						Type: graphql.NewList(Order),
+						Args: graphql.FieldConfigArgument{
+							"store_id": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.UUIDScalar),
+							},
+							"orders": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(OrderArgs))),
+							},
+						},
+						Resolve: resolver.SubmitOrdersResolver,
+					},
+					"setStorePaymentMethods": &graphql.Field{
+						// Updated Store type which can be found in types.go
+						Type: Store,
+						Args: graphql.FieldConfigArgument{
+							"store_id": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.UUIDScalar),
+							},
+							"payment_method_ids": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(scalar.UUIDScalar))),
+							},
+						},
+						Resolve: resolver.SetStorePaymentMethodsResolver,
+					},
+					"createPaymentMethod": &graphql.Field{
+						// Created PaymentMethod type which can be found in types.go
+						Type: PaymentMethod,
+						Args: graphql.FieldConfigArgument{
+							"payment_method": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(PaymentMethodInput),
+							},
+						},
+						Resolve: resolver.CreatePaymentMethodResolver,
+					},
+					"updatePaymentMethod": &graphql.Field{
+						// Updated PaymentMethod type which can be found in types.go
+						Type: PaymentMethod,
+						Args: graphql.FieldConfigArgument{
+							"id": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.UUIDScalar),
+							},
+							"payment_method": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(PaymentMethodInput),
+							},
+						},
+						Resolve: resolver.EditPaymentMethodResolver,
+					},
+					"deletePaymentMethod": &graphql.Field{
+						// Deleted PaymentMethod type which can be found in types.go
+						Type: PaymentMethod,
+						Args: graphql.FieldConfigArgument{
+							"id": &graphql.ArgumentConfig{
+								Type: graphql.NewNonNull(scalar.UUIDScalar),
+							},
+						},
+						Resolve: resolver.DeletePaymentMethodResolver,
+					},
+				},
+			},
+		),
+		Subscription: graphql.NewObject(
+			graphql.ObjectConfig{
+				Name: "Subscription",
+				Fields: graphql.Fields{
+					"storeStatusChanged": &graphql.Field{
+						// StoreStatusEvent type which can be found in types.go
+						Type: StoreStatusEvent,
+						Args: graphql.FieldConfigArgument{
+							"store_id": &graphql.ArgumentConfig{
+								Type: scalar.UUIDScalar,
+							},
+							"vendor_id": &graphql.ArgumentConfig{
+								Type: scalar.UUIDScalar,
+							},
+						},
+						Resolve: resolver.StoreStatusChangedResolver,
+					},
+					"vendorUpdated": &graphql.Field{
+						// Updated Vendor type which can be found in types.go
+						Type: Vendor,
+						Args: graphql.FieldConfigArgument{
+							"id": &graphql.ArgumentConfig{
+								Type: scalar.UUIDScalar,
+							},
+						},
+						Resolve: resolver.VendorUpdatedResolver,
+					},
 				},
 			},
 		),
-		Context: &ctx,
+		client: &client,
+		broker: newBroker(),
 	}
 	return &root
 }
@@ -1,45 +1,348 @@
 package gql
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+
 	"go-graphql-cloud-api/postgres"
+	"go-graphql-cloud-api/scalar"
 
 	"github.com/graph-gophers/dataloader"
 	"github.com/graphql-go/graphql"
+	"github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
 )
 
 // Resolver struct holds a connection to our database
 type Resolver struct {
-	db *postgres.Db
+	db     *postgres.Db
+	config Config
 }
 
-// VendorResolver resolves our settings query through a db call to GetSettings
-// func (r *Resolver) VendorResolver(p graphql.ResolveParams) (interface{}, error) {
-// 	vendors := r.db.GetVendorProducts()
-// 	return vendors, nil
-// }
+// VendorResolver resolves a single vendor through the GetVendors dataloader
 func (r *Resolver) VendorResolver(p graphql.ResolveParams) (interface{}, error) {
+	return loadThunk(p.Context, "GetVendors", p.Args["id"].(uuid.UUID).String()), nil
+}
+
+// VendorsResolver resolves a filtered page of vendors
+func (r *Resolver) VendorsResolver(p graphql.ResolveParams) (interface{}, error) {
+	page, err := pageArgs(p)
+	if err != nil {
+		return nil, err
+	}
+	var filter postgres.VendorFilter
+	if args, ok := p.Args["filter"].(map[string]interface{}); ok {
+		filter.NameContains, _ = args["name_contains"].(string)
+		filter.CreatedAfter = nullTime(args["created_after"])
+		filter.CreatedBefore = nullTime(args["created_before"])
+	}
+
+	result, err := r.db.ListVendors(p.Context, filter, page)
+	if err != nil {
+		return nil, err
+	}
+	return newConnectionResult(page, len(result.Vendors), result.HasNextPage, result.TotalCount, func(i int) (interface{}, postgres.Cursor) {
+		return result.Vendors[i], result.Vendors[i].Cursor(page.OrderBy)
+	}), nil
+}
+
+// ProductResolver resolves a single product through the GetProducts dataloader
+func (r *Resolver) ProductResolver(p graphql.ResolveParams) (interface{}, error) {
+	return loadThunk(p.Context, "GetProducts", p.Args["id"].(uuid.UUID).String()), nil
+}
+
+// ProductByBarcodeResolver resolves the product carrying a barcode
+func (r *Resolver) ProductByBarcodeResolver(p graphql.ResolveParams) (interface{}, error) {
+	product, err := r.db.GetProductByBarcode(p.Context, p.Args["barcode"].(string))
+	if err != nil || product == nil {
+		return nil, err
+	}
+	primeLoader(p.Context, "GetProducts", product.ID, *product)
+	return *product, nil
+}
+
+// ProductsResolver resolves a filtered page of products
+func (r *Resolver) ProductsResolver(p graphql.ResolveParams) (interface{}, error) {
+	page, err := pageArgs(p)
+	if err != nil {
+		return nil, err
+	}
+	var filter postgres.ProductFilter
+	if args, ok := p.Args["filter"].(map[string]interface{}); ok {
+		if filter.VendorID, err = nullID("vendor_id", args["vendor_id"]); err != nil {
+			return nil, err
+		}
+		if filter.SupplierID, err = nullID("supplier_id", args["supplier_id"]); err != nil {
+			return nil, err
+		}
+		filter.IsVirtualProduct.Bool, filter.IsVirtualProduct.Valid = args["is_virtual_product"].(bool)
+		filter.Code.String, filter.Code.Valid = args["code"].(string)
+	}
+
+	result, err := r.db.ListProducts(p.Context, filter, page)
+	if err != nil {
+		return nil, err
+	}
+	return newConnectionResult(page, len(result.Products), result.HasNextPage, result.TotalCount, func(i int) (interface{}, postgres.Cursor) {
+		return result.Products[i], result.Products[i].Cursor(page.OrderBy)
+	}), nil
+}
+
+// SearchProductsResolver resolves the products best matching a text in their
+// localized names, brand names and descriptions
+func (r *Resolver) SearchProductsResolver(p graphql.ResolveParams) (interface{}, error) {
+	text := strings.TrimSpace(p.Args["text"].(string))
+	if text == "" {
+		return nil, errors.New("text must not be empty")
+	}
+	// Search a single language when asked to, otherwise every supported one
+	langs := r.config.Locales.Supported
+	if lang, ok := p.Args["lang"].(string); ok {
+		lang, err := r.config.Locales.check(lang)
+		if err != nil {
+			return nil, err
+		}
+		langs = []string{lang}
+	}
+	first, _ := p.Args["first"].(int)
+	return r.db.SearchProducts(p.Context, text, langs, first)
+}
+
+// SupplierResolver resolves a single supplier through the GetSuppliers dataloader
+func (r *Resolver) SupplierResolver(p graphql.ResolveParams) (interface{}, error) {
+	return loadThunk(p.Context, "GetSuppliers", p.Args["id"].(uuid.UUID).String()), nil
+}
+
+// SuppliersResolver resolves a filtered page of suppliers
+func (r *Resolver) SuppliersResolver(p graphql.ResolveParams) (interface{}, error) {
+	page, err := pageArgs(p)
+	if err != nil {
+		return nil, err
+	}
+	var filter postgres.SupplierFilter
+	if args, ok := p.Args["filter"].(map[string]interface{}); ok {
+		filter.NameContains, _ = args["name_contains"].(string)
+		filter.Code.String, filter.Code.Valid = args["code"].(string)
+	}
+
+	result, err := r.db.ListSuppliers(p.Context, filter, page)
+	if err != nil {
+		return nil, err
+	}
+	return newConnectionResult(page, len(result.Suppliers), result.HasNextPage, result.TotalCount, func(i int) (interface{}, postgres.Cursor) {
+		return result.Suppliers[i], result.Suppliers[i].Cursor(page.OrderBy)
+	}), nil
+}
+
+// StoreResolver resolves a single store by either its id or its code
+func (r *Resolver) StoreResolver(p graphql.ResolveParams) (interface{}, error) {
+	id, hasID := p.Args["id"].(uuid.UUID)
+	code, hasCode := p.Args["code"].(string)
+	if hasID == hasCode {
+		return nil, errors.New("exactly one of id and code must be given")
+	}
+	if hasID {
+		return loadThunk(p.Context, "GetStores", id.String()), nil
+	}
+
+	store, err := r.db.GetStoreByCode(p.Context, code)
+	if err != nil || store == nil {
+		return nil, err
+	}
+	primeLoader(p.Context, "GetStores", store.ID, *store)
+	return *store, nil
+}
+
+// StaleStoresResolver resolves the stores that have not synced or been
+// refilled within olderThan
+func (r *Resolver) StaleStoresResolver(p graphql.ResolveParams) (interface{}, error) {
+	vendorID, err := nullID("vendorId", p.Args["vendorId"])
+	if err != nil {
+		return nil, err
+	}
+	olderThan, err := durationArg("olderThan", p.Args["olderThan"])
+	if err != nil {
+		return nil, err
+	}
+	return r.db.ListStaleStores(p.Context, vendorID, time.Now().Add(-olderThan))
+}
+
+// durationArg returns the positive duration parsed from a String arg such as 90m
+func durationArg(name string, value interface{}) (time.Duration, error) {
+	s, _ := value.(string)
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q, expected a duration such as 24h", name, s)
+	}
+	return d, nil
+}
+
+// LowStockSlotsResolver resolves the stocked slots filled at or below ratio of
+// their capacity
+func (r *Resolver) LowStockSlotsResolver(p graphql.ResolveParams) (interface{}, error) {
+	vendorID, err := nullID("vendor_id", p.Args["vendor_id"])
+	if err != nil {
+		return nil, err
+	}
+	storeID, err := nullID("store_id", p.Args["store_id"])
+	if err != nil {
+		return nil, err
+	}
+	ratio, _ := p.Args["ratio"].(float64)
+	return r.db.ListLowStockSlots(p.Context, vendorID, storeID, ratio)
+}
+
+// StoresResolver resolves a filtered page of stores
+func (r *Resolver) StoresResolver(p graphql.ResolveParams) (interface{}, error) {
+	page, err := pageArgs(p)
+	if err != nil {
+		return nil, err
+	}
+	var filter postgres.StoreFilter
+	if args, ok := p.Args["filter"].(map[string]interface{}); ok {
+		if filter.VendorID, err = nullID("vendor_id", args["vendor_id"]); err != nil {
+			return nil, err
+		}
+		filter.Code.String, filter.Code.Valid = args["code"].(string)
+	}
+
+	result, err := r.db.ListStores(p.Context, filter, page)
+	if err != nil {
+		return nil, err
+	}
+	return newConnectionResult(page, len(result.Stores), result.HasNextPage, result.TotalCount, func(i int) (interface{}, postgres.Cursor) {
+		return result.Stores[i], result.Stores[i].Cursor(page.OrderBy)
+	}), nil
+}
+
+// PaymentMethodResolver resolves a single payment method through the
+// GetPaymentMethods dataloader
+func (r *Resolver) PaymentMethodResolver(p graphql.ResolveParams) (interface{}, error) {
+	return loadThunk(p.Context, "GetPaymentMethods", p.Args["id"].(uuid.UUID).String()), nil
+}
+
+// PaymentMethodsResolver resolves every payment method ordered by order_index
+func (r *Resolver) PaymentMethodsResolver(p graphql.ResolveParams) (interface{}, error) {
+	return r.db.ListPaymentMethods(p.Context)
+}
+
+// SalesReportResolver resolves the sales of a vendor between from and to,
+// grouped by the requested dimensions
+func (r *Resolver) SalesReportResolver(p graphql.ResolveParams) (interface{}, error) {
 	var (
-		v       = p.Context.Value
+		filter postgres.SalesReportFilter
+		err    error
+	)
+	if filter.VendorID, err = nullID("vendorId", p.Args["vendorId"]); err != nil {
+		return nil, err
+	}
+	if filter.From, err = timeArg("from", p.Args["from"]); err != nil {
+		return nil, err
+	}
+	if filter.To, err = timeArg("to", p.Args["to"]); err != nil {
+		return nil, err
+	}
+	items, _ := p.Args["groupBy"].([]interface{})
+	groupBy := make([]string, len(items))
+	for i, item := range items {
+		groupBy[i], _ = item.(string)
+	}
+	report, err := r.db.SalesReport(p.Context, filter, groupBy)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// loadThunk loads key through the named dataloader of this request
+func loadThunk(ctx context.Context, name string, key string) func() (interface{}, error) {
+	var (
+		v       = ctx.Value
 		c       = v("client").(*Client)
 		loaders = v("loaders").(map[string]*dataloader.Loader)
-		id      = p.Args["id"].(string)
-		key     = NewResolverKey(id, c)
 	)
-	thunk := loaders["GetVendors"].Load(p.Context, key)
+	thunk := loaders[name].Load(ctx, NewResolverKey(key, c))
 	return func() (interface{}, error) {
 		return thunk()
-	}, nil
+	}
 }
 
-func (r *Resolver) EditVendorResolver(p graphql.ResolveParams) (interface{}, error) {
-	vendorArgs := p.Args["vendor"].(map[string]interface{})
-	fmt.Println(vendorArgs)
-	k, _ := uuid.FromString("fbc77caf-7dfb-46b4-883f-786b98e012e5")
-	err := r.db.EditVendors(k)
+// nullID parses an optional UUID arg into a uuid.NullUUID
+func nullID(name string, value interface{}) (uuid.NullUUID, error) {
+	if value == nil {
+		return uuid.NullUUID{}, nil
+	}
+	id, err := parseID(name, value)
+	return uuid.NullUUID{UUID: id, Valid: err == nil}, err
+}
+
+// timeArg returns the time parsed from a SpecialDate arg
+func timeArg(name string, value interface{}) (time.Time, error) {
+	switch value := value.(type) {
+	case time.Time:
+		return value, nil
+	case scalar.SpecialDate:
+		return value.Time, nil
+	}
+	return time.Time{}, fmt.Errorf("%s is required", name)
+}
+
+// resolveNullable resolves a field like the default resolver, unwrapping the
+// nullable database values into their value or nil
+func resolveNullable(p graphql.ResolveParams) (interface{}, error) {
+	value, err := graphql.DefaultResolveFn(p)
 	if err != nil {
 		return nil, err
 	}
-	return nil, nil
+	return unwrapNull(value), nil
+}
+
+// unwrapNull returns the value held by a sql, pq or uuid nullable value, or
+// nil when it is null. UUIDs are returned as strings
+func unwrapNull(value interface{}) interface{} {
+	switch value := value.(type) {
+	case sql.NullString:
+		if value.Valid {
+			return value.String
+		}
+		return nil
+	case sql.NullInt64:
+		if value.Valid {
+			return value.Int64
+		}
+		return nil
+	case sql.NullBool:
+		if value.Valid {
+			return value.Bool
+		}
+		return nil
+	case sql.NullFloat64:
+		if value.Valid {
+			return value.Float64
+		}
+		return nil
+	case pq.NullTime:
+		if value.Valid {
+			return value.Time
+		}
+		return nil
+	case uuid.NullUUID:
+		if value.Valid {
+			return value.UUID.String()
+		}
+		return nil
+	case uuid.UUID:
+		return value.String()
+	}
+	return value
+}
+
+// nullTime converts a DateTime arg into a pq.NullTime
+func nullTime(value interface{}) pq.NullTime {
+	t, ok := value.(time.Time)
+	return pq.NullTime{Time: t, Valid: ok}
 }
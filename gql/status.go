@@ -0,0 +1,60 @@
+package gql
+
+import (
+	"fmt"
+	"time"
+
+	"go-graphql-cloud-api/postgres"
+
+	"github.com/graphql-go/graphql"
+)
+
+// StoreStatusThresholds configures how the status of a store is derived from
+// the time it was last online
+type StoreStatusThresholds struct {
+	// StaleAfter is how long a store stays online after its last heartbeat
+	StaleAfter time.Duration
+	// OfflineAfter is how long a store stays stale after its last heartbeat
+	OfflineAfter time.Duration
+}
+
+// Validate returns an error when the thresholds cannot order the statuses, a
+// store must turn stale before it turns offline
+func (t StoreStatusThresholds) Validate() error {
+	if t.StaleAfter < 0 || t.OfflineAfter < 0 {
+		return fmt.Errorf("store status thresholds must not be negative")
+	}
+	if t.StaleAfter > t.OfflineAfter {
+		return fmt.Errorf("stale after %v must not exceed offline after %v", t.StaleAfter, t.OfflineAfter)
+	}
+	return nil
+}
+
+// StoreStatus describes a graphql enum containing the status of a store
+var StoreStatus = graphql.NewEnum(graphql.EnumConfig{
+	Name: "StoreStatus",
+	Values: graphql.EnumValueConfigMap{
+		"ONLINE": &graphql.EnumValueConfig{
+			Value: postgres.StoreOnline,
+		},
+		"STALE": &graphql.EnumValueConfig{
+			Value: postgres.StoreStale,
+		},
+		"OFFLINE": &graphql.EnumValueConfig{
+			Value: postgres.StoreOffline,
+		},
+	},
+})
+
+// storeStatusField resolves the status of a store against the configured thresholds
+var storeStatusField = &graphql.Field{
+	Type: StoreStatus,
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		var (
+			c          = p.Context.Value("client").(*Client)
+			thresholds = c.resolver().config.StoreStatus
+			store      = p.Source.(postgres.Store)
+		)
+		return store.Status(time.Now(), thresholds.StaleAfter, thresholds.OfflineAfter), nil
+	},
+}
@@ -0,0 +1,53 @@
+package gql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStoreStatusThresholdsValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		thresholds StoreStatusThresholds
+		wantErr    bool
+	}{
+		{"defaults", StoreStatusThresholds{StaleAfter: 5 * time.Minute, OfflineAfter: 30 * time.Minute}, false},
+		{"equal", StoreStatusThresholds{StaleAfter: time.Hour, OfflineAfter: time.Hour}, false},
+		{"stale after offline", StoreStatusThresholds{StaleAfter: time.Hour, OfflineAfter: 30 * time.Minute}, true},
+		{"negative", StoreStatusThresholds{StaleAfter: -time.Minute, OfflineAfter: time.Hour}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.thresholds.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDurationArg(t *testing.T) {
+	tests := []struct {
+		value   interface{}
+		want    time.Duration
+		wantErr bool
+	}{
+		{"24h", 24 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"0s", 0, true},
+		{"-1h", 0, true},
+		{"1d", 0, true},
+		{"24", 0, true},
+		{"", 0, true},
+		{nil, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := durationArg("olderThan", tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("durationArg(%v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("durationArg(%v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go-graphql-cloud-api/postgres"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	uuid "github.com/satori/go.uuid"
+)
+
+// statusCheckInterval is how often the status of the stores is derived again,
+// since it changes with time even when a store is not written to
+const statusCheckInterval = 15 * time.Second
+
+// storeStatusEvent is published on storeStatusChanged whenever the status
+// derived for a store changes
+type storeStatusEvent struct {
+	Store          postgres.Store `json:"store"`
+	Status         string         `json:"status"`
+	PreviousStatus string         `json:"previous_status"`
+}
+
+// StoreStatusChangedResolver resolves the store status change being delivered
+// when it concerns the subscribed store and vendor
+func (r *Resolver) StoreStatusChangedResolver(p graphql.ResolveParams) (interface{}, error) {
+	event, ok := subscriptionEvent(p).(storeStatusEvent)
+	if !ok {
+		return nil, nil
+	}
+	storeID, err := nullID("store_id", p.Args["store_id"])
+	if err != nil {
+		return nil, err
+	}
+	vendorID, err := nullID("vendor_id", p.Args["vendor_id"])
+	if err != nil {
+		return nil, err
+	}
+	if storeID.Valid && storeID.UUID != event.Store.ID || vendorID.Valid && vendorID != event.Store.VendorID {
+		return nil, nil
+	}
+	return event, nil
+}
+
+// VendorUpdatedResolver resolves the vendor update being delivered when it
+// concerns the subscribed vendor
+func (r *Resolver) VendorUpdatedResolver(p graphql.ResolveParams) (interface{}, error) {
+	vendor, ok := subscriptionEvent(p).(postgres.Vendor)
+	if !ok {
+		return nil, nil
+	}
+	id, err := nullID("id", p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	if id.Valid && id.UUID != vendor.ID {
+		return nil, nil
+	}
+	return vendor, nil
+}
+
+// subscriptionEvent returns the event a subscription field is executed for,
+// it is nil when the field is executed outside of Subscribe
+func subscriptionEvent(p graphql.ResolveParams) interface{} {
+	events, _ := p.Source.(map[string]interface{})
+	return events[p.Info.FieldName]
+}
+
+// Subscribe executes request, a subscription selecting a single field, once
+// for every event published on that field until ctx is done. Events filtered
+// out by the field are skipped
+func (r *Root) Subscribe(ctx context.Context, schema graphql.Schema, request Request) (<-chan *graphql.Result, error) {
+	doc, err := parser.Parse(parser.ParseParams{Source: request.Query})
+	if err != nil {
+		return nil, err
+	}
+	if validation := graphql.ValidateDocument(&schema, doc, nil); !validation.IsValid {
+		messages := make([]string, len(validation.Errors))
+		for i, err := range validation.Errors {
+			messages[i] = err.Message
+		}
+		return nil, errors.New(strings.Join(messages, "; "))
+	}
+
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		d, ok := definition.(*ast.OperationDefinition)
+		if !ok || request.OperationName != "" && (d.Name == nil || d.Name.Value != request.OperationName) {
+			continue
+		}
+		if operation != nil {
+			return nil, errors.New("operationName is required when the query holds several operations")
+		}
+		operation = d
+	}
+	if operation == nil {
+		return nil, fmt.Errorf("unknown operation %q", request.OperationName)
+	}
+	if operation.Operation != ast.OperationTypeSubscription {
+		return nil, fmt.Errorf("%s operations cannot be subscribed to", operation.Operation)
+	}
+	var field *ast.Field
+	if selections := operation.SelectionSet.Selections; len(selections) == 1 {
+		field, _ = selections[0].(*ast.Field)
+	}
+	if field == nil {
+		return nil, errors.New("a subscription must select exactly one field")
+	}
+	key := field.Name.Value
+	if field.Alias != nil {
+		key = field.Alias.Value
+	}
+
+	events, cancel := r.broker.subscribe(field.Name.Value)
+	results := make(chan *graphql.Result)
+	go func() {
+		defer close(results)
+		defer cancel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-events:
+				// Every event is executed with its own dataloaders
+				result := graphql.Do(graphql.Params{
+					Schema:         schema,
+					RequestString:  request.Query,
+					RootObject:     map[string]interface{}{field.Name.Value: event},
+					VariableValues: request.Variables,
+					OperationName:  request.OperationName,
+					Context:        r.NewContext(ctx),
+				})
+				if data, ok := result.Data.(map[string]interface{}); ok && data[key] == nil && len(result.Errors) == 0 {
+					continue
+				}
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return results, nil
+}
+
+// Watch feeds our subscriptions from the changes our database notifies us of
+// until ctx is done
+func (r *Root) Watch(ctx context.Context) error {
+	var (
+		db         = r.client.resolver().db
+		thresholds = r.client.resolver().config.StoreStatus
+	)
+	notifications, err := db.Listen(ctx, postgres.StoreChannel, postgres.VendorChannel)
+	if err != nil {
+		return err
+	}
+	statuses := storeStatuses{
+		thresholds: thresholds,
+		stores:     make(map[uuid.UUID]postgres.Store),
+		statuses:   make(map[uuid.UUID]string),
+		publish: func(event storeStatusEvent) {
+			r.broker.publish("storeStatusChanged", event)
+		},
+	}
+	// Stores that were not online recently are offline until they notify us
+	stores, err := db.ListStoresOnlineSince(ctx, time.Now().Add(-thresholds.OfflineAfter))
+	if err != nil {
+		return err
+	}
+	for _, store := range stores {
+		statuses.update(store, time.Now())
+	}
+
+	go func() {
+		ticker := time.NewTicker(statusCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				statuses.check(time.Now())
+			case n, ok := <-notifications:
+				if !ok {
+					return
+				}
+				switch n.Channel {
+				case postgres.StoreChannel:
+					stores, err := db.GetStores(ctx, []uuid.UUID{n.ID})
+					if err != nil {
+						fmt.Println(err)
+						continue
+					}
+					for _, store := range stores {
+						statuses.update(store, time.Now())
+					}
+				case postgres.VendorChannel:
+					vendors, err := db.GetVendors(ctx, []uuid.UUID{n.ID})
+					if err != nil {
+						fmt.Println(err)
+						continue
+					}
+					for _, vendor := range vendors {
+						r.broker.publish("vendorUpdated", vendor)
+					}
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// storeStatuses tracks the last status published for every store that is
+// not offline
+type storeStatuses struct {
+	thresholds StoreStatusThresholds
+	stores     map[uuid.UUID]postgres.Store
+	statuses   map[uuid.UUID]string
+	publish    func(storeStatusEvent)
+}
+
+// update derives the status of store at now and publishes it when it changed
+func (s *storeStatuses) update(store postgres.Store, now time.Time) {
+	status := store.Status(now, s.thresholds.StaleAfter, s.thresholds.OfflineAfter)
+	previous, ok := s.statuses[store.ID]
+	if !ok {
+		previous = postgres.StoreOffline
+	}
+	if status == postgres.StoreOffline {
+		delete(s.stores, store.ID)
+		delete(s.statuses, store.ID)
+	} else {
+		s.stores[store.ID] = store
+		s.statuses[store.ID] = status
+	}
+	if status != previous {
+		s.publish(storeStatusEvent{Store: store, Status: status, PreviousStatus: previous})
+	}
+}
+
+// check derives the status of every tracked store again
+func (s *storeStatuses) check(now time.Time) {
+	for _, store := range s.stores {
+		s.update(store, now)
+	}
+}
+
+// broker fans the events published on a topic out to its subscribers
+type broker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan interface{}]bool
+}
+
+func newBroker() *broker {
+	return &broker{subscribers: make(map[string]map[chan interface{}]bool)}
+}
+
+// subscribe returns a channel receiving the events published on topic until
+// cancel is called
+func (b *broker) subscribe(topic string) (<-chan interface{}, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	events := make(chan interface{}, 16)
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[chan interface{}]bool)
+	}
+	b.subscribers[topic][events] = true
+	return events, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers[topic], events)
+	}
+}
+
+// publish delivers event to every subscriber of topic, subscribers too slow
+// to keep up miss it
+func (b *broker) publish(topic string, event interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for events := range b.subscribers[topic] {
+		select {
+		case events <- event:
+		default:
+			fmt.Printf("[broker] dropped %s event for a slow subscriber\n", topic)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/graph-gophers/dataloader"
 	"github.com/graphql-go/graphql"
+	uuid "github.com/satori/go.uuid"
 )
 
 type Client struct {
@@ -40,34 +41,32 @@ func (rk *ResolverKey) Raw() interface{} {
 	return rk.Key
 }
 
-var LanguageJson = graphql.NewObject(graphql.ObjectConfig{
-	Name: "LanguageJson",
-	Fields: graphql.Fields{
-		"en": &graphql.Field{Type: scalar.NullScalar},
-		"zh": &graphql.Field{Type: scalar.NullScalar},
-	},
-})
-
 var Vendor = graphql.NewObject(graphql.ObjectConfig{
 	Name: "Vendor",
 	Fields: graphql.Fields{
 		"id": &graphql.Field{
-			Type: scalar.NullScalar,
+			Type:    scalar.UUIDScalar,
+			Resolve: resolveNullable,
 		},
 		"created_at": &graphql.Field{
-			Type: scalar.NullScalar,
+			Type:    scalar.DateTimeScalar,
+			Resolve: resolveNullable,
 		},
 		"updated_at": &graphql.Field{
-			Type: scalar.NullScalar,
+			Type:    scalar.DateTimeScalar,
+			Resolve: resolveNullable,
 		},
 		"mongo_id": &graphql.Field{
-			Type: scalar.NullScalar,
+			Type:    graphql.ID,
+			Resolve: resolveNullable,
 		},
 		"name": &graphql.Field{
-			Type: scalar.NullScalar,
+			Type:    graphql.String,
+			Resolve: resolveNullable,
 		},
 		"description": &graphql.Field{
-			Type: scalar.NullScalar,
+			Type:    graphql.String,
+			Resolve: resolveNullable,
 		},
 		"products": &graphql.Field{
 			Type: graphql.NewList(Product),
@@ -104,34 +103,45 @@ var Vendor = graphql.NewObject(graphql.ObjectConfig{
 	},
 })
 
+// VendorConnection describes a graphql object containing a page of Vendors
+var VendorConnection = newConnection(Vendor)
+
 // Product describes a graphql object containing a Product
 var Product = graphql.NewObject(
 	graphql.ObjectConfig{
 		Name: "Product",
 		Fields: graphql.Fields{
 			"id": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
 			},
 			"created_at": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
 			},
 			"updated_at": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
 			},
 			"mongo_id": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    graphql.ID,
+				Resolve: resolveNullable,
 			},
 			"photo": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    graphql.String,
+				Resolve: resolveNullable,
 			},
 			"code": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    graphql.String,
+				Resolve: resolveNullable,
 			},
 			"is_virtual_product": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    graphql.Boolean,
+				Resolve: resolveNullable,
 			},
 			"barcode": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    graphql.String,
+				Resolve: resolveNullable,
 			},
 			"descriptions": &graphql.Field{
 				Type: LanguageJson,
@@ -143,67 +153,546 @@ var Product = graphql.NewObject(
 				Type: LanguageJson,
 			},
 			"optional_data": &graphql.Field{
-				Type: LanguageJson,
+				Type: scalar.JSONObjectScalar,
 			},
+			"name": localizedField("The names in a single language", func(source interface{}) postgres.LanguageJson {
+				return source.(postgres.Product).Names
+			}),
+			"brand_name": localizedField("The brand names in a single language", func(source interface{}) postgres.LanguageJson {
+				return source.(postgres.Product).BrandNames
+			}),
+			"description": localizedField("The descriptions in a single language", func(source interface{}) postgres.LanguageJson {
+				return source.(postgres.Product).Descriptions
+			}),
 			"vendor_id": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
 			},
 			"supplier_id": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
+			},
+			"supplier": &graphql.Field{
+				Type: Supplier,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					product := p.Source.(postgres.Product)
+					if !product.SupplierID.Valid {
+						return nil, nil
+					}
+					return loadThunk(p.Context, "GetSuppliers", product.SupplierID.UUID.String()), nil
+				},
 			},
 		},
 	},
 )
 
+// ProductConnection describes a graphql object containing a page of Products
+var ProductConnection = newConnection(Product)
+
+// ProductSearchResult describes a graphql object containing a Product matched by a search
+var ProductSearchResult = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ProductSearchResult",
+	Fields: graphql.Fields{
+		"product": &graphql.Field{
+			Type: Product,
+		},
+		"lang": &graphql.Field{
+			Type: graphql.String,
+		},
+		"rank": &graphql.Field{
+			Type: graphql.Float,
+		},
+	},
+})
+
+// Supplier describes a graphql object containing a Supplier
+var Supplier = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Supplier",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
+			},
+			"created_at": &graphql.Field{
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
+			},
+			"updated_at": &graphql.Field{
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
+			},
+			"mongo_id": &graphql.Field{
+				Type:    graphql.ID,
+				Resolve: resolveNullable,
+			},
+			"code": &graphql.Field{
+				Type:    graphql.String,
+				Resolve: resolveNullable,
+			},
+			"name": &graphql.Field{
+				Type:    graphql.String,
+				Resolve: resolveNullable,
+			},
+			"contact_name": &graphql.Field{
+				Type:    graphql.String,
+				Resolve: resolveNullable,
+			},
+			"phone": &graphql.Field{
+				Type:    graphql.String,
+				Resolve: resolveNullable,
+			},
+			"email": &graphql.Field{
+				Type:    graphql.String,
+				Resolve: resolveNullable,
+			},
+			"address": &graphql.Field{
+				Type:    graphql.String,
+				Resolve: resolveNullable,
+			},
+		},
+	},
+)
+
+// SupplierConnection describes a graphql object containing a page of Suppliers
+var SupplierConnection = newConnection(Supplier)
+
+// PaymentMethod describes a graphql object containing a PaymentMethod
+var PaymentMethod = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "PaymentMethod",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
+			},
+			"created_at": &graphql.Field{
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
+			},
+			"updated_at": &graphql.Field{
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
+			},
+			"mongo_id": &graphql.Field{
+				Type:    graphql.ID,
+				Resolve: resolveNullable,
+			},
+			"code": &graphql.Field{
+				Type:    graphql.String,
+				Resolve: resolveNullable,
+			},
+			"name": &graphql.Field{
+				Type:    graphql.String,
+				Resolve: resolveNullable,
+			},
+			"module": &graphql.Field{
+				Type:    graphql.String,
+				Resolve: resolveNullable,
+			},
+			"module_channel": &graphql.Field{
+				Type:    graphql.String,
+				Resolve: resolveNullable,
+			},
+			"order_index": &graphql.Field{
+				Type: graphql.Int,
+			},
+		},
+	},
+)
+
+// InventorySlot describes a graphql object containing a slot of a Store
+var InventorySlot = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "InventorySlot",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
+			},
+			"created_at": &graphql.Field{
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
+			},
+			"updated_at": &graphql.Field{
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
+			},
+			"store_id": &graphql.Field{
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
+			},
+			"product_id": &graphql.Field{
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
+			},
+			"product": &graphql.Field{
+				Type: Product,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					slot := p.Source.(postgres.InventorySlot)
+					if !slot.ProductID.Valid {
+						return nil, nil
+					}
+					return loadThunk(p.Context, "GetProducts", slot.ProductID.UUID.String()), nil
+				},
+			},
+			"slot": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"capacity": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"quantity": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"price": moneyField("The price of a unit", func(source interface{}) int64 {
+				return source.(postgres.InventorySlot).Price
+			}),
+		},
+	},
+)
+
 // Store describes a graphql object containing a Store
 var Store = graphql.NewObject(
 	graphql.ObjectConfig{
 		Name: "Store",
 		Fields: graphql.Fields{
 			"id": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
 			},
 			"created_at": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
 			},
 			"updated_at": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
 			},
 			"mongo_id": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    graphql.ID,
+				Resolve: resolveNullable,
 			},
 			"code": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    graphql.String,
+				Resolve: resolveNullable,
 			},
 			"name": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    graphql.String,
+				Resolve: resolveNullable,
 			},
 			"model": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    graphql.String,
+				Resolve: resolveNullable,
 			},
 			"address": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    graphql.String,
+				Resolve: resolveNullable,
 			},
 			"last_online_at": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
 			},
 			"last_get": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
 			},
 			"last_refill": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
 			},
 			"last_reset": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
 			},
 			"last_sync": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
 			},
 			"unsubmitted_order_count": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    graphql.Int,
+				Resolve: resolveNullable,
+			},
+			"status": storeStatusField,
+			"inventory": &graphql.Field{
+				Type: graphql.NewList(InventorySlot),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					store := p.Source.(postgres.Store)
+					return loadThunk(p.Context, "GetStoreInventory", store.ID.String()), nil
+				},
+			},
+			"payment_methods": &graphql.Field{
+				Type: graphql.NewList(PaymentMethod),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					store := p.Source.(postgres.Store)
+					return loadThunk(p.Context, "GetStorePaymentMethods", store.ID.String()), nil
+				},
 			},
 			"vendor_id": &graphql.Field{
-				Type: scalar.NullScalar,
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
+			},
+		},
+	},
+)
+
+// StoreConnection describes a graphql object containing a page of Stores
+var StoreConnection = newConnection(Store)
+
+// Products and stores point back at the Vendor that lists them and slots at
+// the Store that lists them, so these fields are only added once all the
+// types exist
+func init() {
+	Product.AddFieldConfig("vendor", vendorField(func(source interface{}) uuid.NullUUID {
+		return source.(postgres.Product).VendorID
+	}))
+	Store.AddFieldConfig("vendor", vendorField(func(source interface{}) uuid.NullUUID {
+		return source.(postgres.Store).VendorID
+	}))
+	InventorySlot.AddFieldConfig("store", &graphql.Field{
+		Type: Store,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			slot := p.Source.(postgres.InventorySlot)
+			return loadThunk(p.Context, "GetStores", slot.StoreID.String()), nil
+		},
+	})
+}
+
+// vendorField resolves the vendor owning the source through the GetVendors dataloader
+func vendorField(vendorID func(source interface{}) uuid.NullUUID) *graphql.Field {
+	return &graphql.Field{
+		Type: Vendor,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			id := vendorID(p.Source)
+			if !id.Valid {
+				return nil, nil
+			}
+			return loadThunk(p.Context, "GetVendors", id.UUID.String()), nil
+		},
+	}
+}
+
+// OrderItem describes a graphql object containing an item of an Order
+var OrderItem = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "OrderItem",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
+			},
+			"created_at": &graphql.Field{
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
+			},
+			"order_id": &graphql.Field{
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
+			},
+			"product_id": &graphql.Field{
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
+			},
+			"product": &graphql.Field{
+				Type: Product,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					item := p.Source.(postgres.OrderItem)
+					if !item.ProductID.Valid {
+						return nil, nil
+					}
+					return loadThunk(p.Context, "GetProducts", item.ProductID.UUID.String()), nil
+				},
+			},
+			"slot": &graphql.Field{
+				Type:    graphql.Int,
+				Resolve: resolveNullable,
+			},
+			"quantity": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"unit_price": moneyField("The price of a unit", func(source interface{}) int64 {
+				return source.(postgres.OrderItem).UnitPrice
+			}),
+		},
+	},
+)
+
+// Order describes a graphql object containing an Order submitted by a Store
+var Order = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Order",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
+			},
+			"created_at": &graphql.Field{
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
+			},
+			"updated_at": &graphql.Field{
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
+			},
+			"store_id": &graphql.Field{
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
+			},
+			"store": &graphql.Field{
+				Type: Store,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					order := p.Source.(postgres.Order)
+					return loadThunk(p.Context, "GetStores", order.StoreID.String()), nil
+				},
+			},
+			"client_order_id": &graphql.Field{
+				Type:    graphql.ID,
+				Resolve: resolveNullable,
+			},
+			"payment_method_id": &graphql.Field{
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
+			},
+			"payment_method": &graphql.Field{
+				Type: PaymentMethod,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					order := p.Source.(postgres.Order)
+					if !order.PaymentMethodID.Valid {
+						return nil, nil
+					}
+					return loadThunk(p.Context, "GetPaymentMethods", order.PaymentMethodID.UUID.String()), nil
+				},
+			},
+			"ordered_at": &graphql.Field{
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
+			},
+			"total": moneyField("The total of the items", func(source interface{}) int64 {
+				return source.(postgres.Order).Total
+			}),
+			"items": &graphql.Field{
+				Type: graphql.NewList(OrderItem),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					order := p.Source.(postgres.Order)
+					return loadThunk(p.Context, "GetOrderItems", order.ID.String()), nil
+				},
+			},
+		},
+	},
+)
+
+// SalesTotals describes a graphql object containing aggregated orders
+var SalesTotals = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "SalesTotals",
+		Fields: graphql.Fields{
+			"orders": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"units": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"revenue": moneyField("The total of the orders", func(source interface{}) int64 {
+				return source.(postgres.SalesTotals).Revenue
+			}),
+		},
+	},
+)
+
+// SalesReportRow describes a graphql object containing the orders aggregated
+// for the values of the dimensions a SalesReport is grouped by
+var SalesReportRow = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "SalesReportRow",
+		Fields: graphql.Fields{
+			"store_id": &graphql.Field{
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
+			},
+			"store": &graphql.Field{
+				Type: Store,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					row := p.Source.(postgres.SalesRow)
+					if !row.StoreID.Valid {
+						return nil, nil
+					}
+					return loadThunk(p.Context, "GetStores", row.StoreID.UUID.String()), nil
+				},
+			},
+			"product_id": &graphql.Field{
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
+			},
+			"product": &graphql.Field{
+				Type: Product,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					row := p.Source.(postgres.SalesRow)
+					if !row.ProductID.Valid {
+						return nil, nil
+					}
+					return loadThunk(p.Context, "GetProducts", row.ProductID.UUID.String()), nil
+				},
+			},
+			"day": &graphql.Field{
+				Type:    scalar.DateTimeScalar,
+				Resolve: resolveNullable,
+			},
+			"payment_method_id": &graphql.Field{
+				Type:    scalar.UUIDScalar,
+				Resolve: resolveNullable,
+			},
+			"payment_method": &graphql.Field{
+				Type: PaymentMethod,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					row := p.Source.(postgres.SalesRow)
+					if !row.PaymentMethodID.Valid {
+						return nil, nil
+					}
+					return loadThunk(p.Context, "GetPaymentMethods", row.PaymentMethodID.UUID.String()), nil
+				},
+			},
+			"orders": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"units": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"revenue": moneyField("The total of the orders", func(source interface{}) int64 {
+				return source.(postgres.SalesRow).Revenue
+			}),
+		},
+	},
+)
+
+// SalesReport describes a graphql object containing a sales report
+var SalesReport = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "SalesReport",
+		Fields: graphql.Fields{
+			"rows": &graphql.Field{
+				Type: graphql.NewList(SalesReportRow),
+			},
+			"totals": &graphql.Field{
+				Type: SalesTotals,
+			},
+		},
+	},
+)
+
+// StoreStatusEvent describes a graphql object containing a change to the
+// status of a Store
+var StoreStatusEvent = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "StoreStatusEvent",
+		Fields: graphql.Fields{
+			"store": &graphql.Field{
+				Type: Store,
+			},
+			"status": &graphql.Field{
+				Type: StoreStatus,
+			},
+			"previous_status": &graphql.Field{
+				Type: StoreStatus,
 			},
 		},
 	},
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
+	"crypto/rsa"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"go-graphql-cloud-api/ciphers"
 	"go-graphql-cloud-api/gql"
@@ -64,11 +70,36 @@ func initializeAPI() (*chi.Mux, *postgres.Db) {
 		fmt.Println("database has been set up")
 	}
 
+	locales := gql.Locales{
+		Supported: splitList(os.Getenv("SUPPORTED_LANGUAGES"), "en,zh"),
+		Fallback:  splitList(os.Getenv("LANGUAGE_FALLBACK"), "en,zh"),
+	}
+	// Searching a supported language must not scan every product
+	if err := db.CreateSearchIndexes(context.Background(), locales.Supported); err != nil {
+		log.Fatal(err)
+	}
+
+	storeStatus := gql.StoreStatusThresholds{
+		StaleAfter:   duration("STORE_STALE_AFTER", "5m"),
+		OfflineAfter: duration("STORE_OFFLINE_AFTER", "30m"),
+	}
+	if err := storeStatus.Validate(); err != nil {
+		log.Fatalf("Error loading STORE_STALE_AFTER and STORE_OFFLINE_AFTER: %v", err)
+	}
+
 	// Create our root query for graphql
-	rootQuery := gql.NewRoot(db)
+	rootQuery := gql.NewRoot(db, gql.Config{
+		Locales:     locales,
+		StoreStatus: storeStatus,
+		Currency:    setting("CURRENCY", "EUR"),
+	})
+	// Feed our subscriptions from the changes notified by the database
+	if err := rootQuery.Watch(context.Background()); err != nil {
+		log.Fatal(err)
+	}
 	// Create a new graphql schema, passing in the the root query
 	sc, err := graphql.NewSchema(
-		graphql.SchemaConfig{Query: rootQuery.Query, Mutation: rootQuery.Mutation},
+		graphql.SchemaConfig{Query: rootQuery.Query, Mutation: rootQuery.Mutation, Subscription: rootQuery.Subscription},
 	)
 	if err != nil {
 		fmt.Println("Error creating schema: ", err)
@@ -79,9 +110,14 @@ func initializeAPI() (*chi.Mux, *postgres.Db) {
 	// Create a server struct that holds a pointer to our database as well
 	// as the address of our graphql schema
 	s := server.Server{
-		GqlSchema: &sc,
-		Context:   rootQuery.Context,
+		Db:             db,
+		GqlSchema:      &sc,
+		NewContext:     rootQuery.NewContext,
+		Subscribe:      rootQuery.Subscribe,
+		Authenticator:  server.NewSignatureAuthenticator(loadClientKeys()),
+		AllowedOrigins: splitList(os.Getenv("ALLOWED_ORIGINS"), ""),
 	}
+	s.PersistedQueries = loadPersistedQueries(db)
 
 	// Add some middleware to our router
 	router.Use(
@@ -92,12 +128,134 @@ func initializeAPI() (*chi.Mux, *postgres.Db) {
 		middleware.Recoverer,       // recover from panics without crashing server
 	)
 
-	// Create the graphql route with a Server method to handle it
+	// Create the graphql route with a Server method to handle it, queries
+	// may also be sent with GET
 	router.Post(os.Getenv("GRAPHQL_LINK"), s.GraphQL())
+	router.Get(os.Getenv("GRAPHQL_LINK"), s.GraphQL())
+	// Subscriptions are served over WebSocket with the graphql-ws protocol
+	router.Get(setting("GRAPHQL_WS_LINK", "/subscriptions"), s.Subscriptions())
+	// Lightweight endpoint for devices reporting heartbeats and syncs
+	router.Post("/stores/{id}/telemetry", s.StoreTelemetry())
 
 	return router, db
 }
 
+// splitList splits a comma separated setting, using def when it is not set
+func splitList(setting string, def string) []string {
+	if strings.TrimSpace(setting) == "" {
+		setting = def
+	}
+	var list []string
+	for _, item := range strings.Split(setting, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// setting returns the value of the environment variable name, using def when
+// it is not set
+func setting(name string, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
+
+// duration parses a duration setting such as 5m, using def when it is not set
+func duration(name string, def string) time.Duration {
+	d, err := time.ParseDuration(setting(name, def))
+	if err != nil {
+		log.Fatalf("Error loading %s: %v", name, err)
+	}
+	return d
+}
+
+// loadClientKeys reads the public keys of the clients allowed to call the api
+// from CLIENT_PUBLIC_KEYS, formatted as "clientID=public.pem,otherID=other.pem".
+// Every client must have a key of its own, or its requests could not be told
+// apart from those of the other clients sharing the key
+func loadClientKeys() map[string]*rsa.PublicKey {
+	keys := make(map[string]*rsa.PublicKey)
+	owners := make(map[string]string)
+	for _, entry := range strings.Split(os.Getenv("CLIENT_PUBLIC_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pair := strings.SplitN(entry, "=", 2)
+		if len(pair) != 2 {
+			log.Fatalf("Error loading CLIENT_PUBLIC_KEYS entry: %s", entry)
+		}
+		if _, ok := keys[pair[0]]; ok {
+			log.Fatalf("Error loading CLIENT_PUBLIC_KEYS: client %s is listed twice", pair[0])
+		}
+		key := ciphers.LoadRSAPublicPemKey(pair[1])
+		encoded := string(ciphers.PublicKeyToBytes(key))
+		if owner, ok := owners[encoded]; ok {
+			log.Fatalf("Error loading CLIENT_PUBLIC_KEYS: clients %s and %s share a key", owner, pair[0])
+		}
+		owners[encoded] = pair[0]
+		keys[pair[0]] = key
+	}
+	if len(keys) == 0 {
+		log.Fatal("Error loading CLIENT_PUBLIC_KEYS")
+	}
+	return keys
+}
+
+// loadPersistedQueries sets up the persisted queries from PERSISTED_QUERIES,
+// which is "apq" to let clients register queries, "allowlist" to only execute
+// the registered queries or "off". The queries are kept in memory, or in
+// postgres as well when PERSISTED_QUERIES_STORE is "postgres", and the
+// .graphql files of PERSISTED_QUERIES_DIR are registered at startup. At most
+// PERSISTED_QUERIES_MAX queries registered by clients are kept in memory
+func loadPersistedQueries(db *postgres.Db) *server.PersistedQueries {
+	mode := setting("PERSISTED_QUERIES", "apq")
+	if mode == "off" {
+		return nil
+	}
+	if mode != "apq" && mode != "allowlist" {
+		log.Fatalf("Error loading PERSISTED_QUERIES: unknown mode %q", mode)
+	}
+	p := server.NewPersistedQueries(mode == "allowlist")
+	if max := os.Getenv("PERSISTED_QUERIES_MAX"); max != "" {
+		n, err := strconv.Atoi(max)
+		if err != nil || n < 0 {
+			log.Fatalf("Error loading PERSISTED_QUERIES_MAX: invalid count %q", max)
+		}
+		p.MaxQueries = n
+	}
+	if setting("PERSISTED_QUERIES_STORE", "memory") == "postgres" {
+		p.Db = db
+		if err := p.Load(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	dir := os.Getenv("PERSISTED_QUERIES_DIR")
+	if dir == "" {
+		return p
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.graphql"))
+	if err != nil {
+		log.Fatalf("Error loading PERSISTED_QUERIES_DIR: %v", err)
+	}
+	for _, file := range files {
+		query, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Fatalf("Error loading persisted query %s: %v", file, err)
+		}
+		hash, err := p.Register(context.Background(), string(query))
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Registered persisted query %s: %s\n", filepath.Base(file), hash)
+	}
+	return p
+}
+
 func initPem() {
 	// err := ciphers.GenerateKeyPair(1024, "private.pem", "public.pem")
 	// if err != nil {
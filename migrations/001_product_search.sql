@@ -0,0 +1,18 @@
+-- Trigram indexes backing searchProducts. Every localized name, brand name and
+-- description is indexed by language so both ILIKE substring matches and the
+-- similarity operator (%) used for english text can use them. The indexes of
+-- en and zh are created here, those of the other SUPPORTED_LANGUAGES are
+-- created with the same names by the api when it starts.
+--
+-- Chinese text is matched by substring only. pg_trgm only extracts trigrams
+-- from CJK characters when the database LC_CTYPE classifies them as letters
+-- (e.g. en_US.UTF-8 or zh_HK.UTF-8, not C), otherwise those lookups fall back
+-- to a sequential scan but still return correct results.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS product_names_en_trgm_idx ON product USING gin ((names->>'en') gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS product_names_zh_trgm_idx ON product USING gin ((names->>'zh') gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS product_brand_names_en_trgm_idx ON product USING gin ((brand_names->>'en') gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS product_brand_names_zh_trgm_idx ON product USING gin ((brand_names->>'zh') gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS product_descriptions_en_trgm_idx ON product USING gin ((descriptions->>'en') gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS product_descriptions_zh_trgm_idx ON product USING gin ((descriptions->>'zh') gin_trgm_ops);
@@ -0,0 +1,16 @@
+-- History of the telemetry reported by stores, one row per heartbeat or sync.
+-- The latest values are also kept on the store row itself.
+CREATE TABLE IF NOT EXISTS store_telemetry (
+	id uuid PRIMARY KEY,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	store_id uuid NOT NULL REFERENCES store (id) ON DELETE CASCADE,
+	event text NOT NULL,
+	online boolean NOT NULL,
+	synced_at timestamptz,
+	last_get timestamptz,
+	last_refill timestamptz,
+	last_reset timestamptz,
+	unsubmitted_order_count integer
+);
+
+CREATE INDEX IF NOT EXISTS store_telemetry_store_id_created_at_idx ON store_telemetry (store_id, created_at DESC);
@@ -0,0 +1,16 @@
+-- Announce changed stores and vendors to the api, which LISTENs on these
+-- channels to feed graphql subscriptions. The payload is the id of the row.
+CREATE OR REPLACE FUNCTION notify_row_changed() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_notify(TG_ARGV[0], NEW.id::text);
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS store_changed ON store;
+CREATE TRIGGER store_changed AFTER INSERT OR UPDATE ON store
+	FOR EACH ROW EXECUTE PROCEDURE notify_row_changed('store_changed');
+
+DROP TRIGGER IF EXISTS vendor_changed ON vendor;
+CREATE TRIGGER vendor_changed AFTER UPDATE ON vendor
+	FOR EACH ROW EXECUTE PROCEDURE notify_row_changed('vendor_changed');
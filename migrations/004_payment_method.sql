@@ -0,0 +1,21 @@
+-- Payment methods and the stores accepting them.
+CREATE TABLE IF NOT EXISTS payment_method (
+	id uuid PRIMARY KEY,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	updated_at timestamptz NOT NULL DEFAULT now(),
+	mongo_id text,
+	code text NOT NULL,
+	name text NOT NULL,
+	module text,
+	module_channel text,
+	order_index integer NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS store_payment_method (
+	store_id uuid NOT NULL REFERENCES store (id) ON DELETE CASCADE,
+	payment_method_id uuid NOT NULL REFERENCES payment_method (id) ON DELETE CASCADE,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	PRIMARY KEY (store_id, payment_method_id)
+);
+
+CREATE INDEX IF NOT EXISTS store_payment_method_payment_method_id_idx ON store_payment_method (payment_method_id);
@@ -0,0 +1,15 @@
+-- Suppliers products are procured from, referenced by product.supplier_id.
+CREATE TABLE IF NOT EXISTS supplier (
+	id uuid PRIMARY KEY,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	updated_at timestamptz NOT NULL DEFAULT now(),
+	mongo_id text,
+	code text,
+	name text NOT NULL,
+	contact_name text,
+	phone text,
+	email text,
+	address text
+);
+
+CREATE INDEX IF NOT EXISTS product_supplier_id_idx ON product (supplier_id);
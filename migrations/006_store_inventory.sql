@@ -0,0 +1,16 @@
+-- The slots of every store and the product stocked in them. Prices are in
+-- the smallest unit of the currency.
+CREATE TABLE IF NOT EXISTS store_inventory (
+	id uuid PRIMARY KEY,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	updated_at timestamptz NOT NULL DEFAULT now(),
+	store_id uuid NOT NULL REFERENCES store (id) ON DELETE CASCADE,
+	product_id uuid REFERENCES product (id) ON DELETE SET NULL,
+	slot integer NOT NULL,
+	capacity integer NOT NULL DEFAULT 0 CHECK (capacity >= 0),
+	quantity integer NOT NULL DEFAULT 0 CHECK (quantity >= 0 AND quantity <= capacity),
+	price bigint NOT NULL DEFAULT 0 CHECK (price >= 0),
+	UNIQUE (store_id, slot)
+);
+
+CREATE INDEX IF NOT EXISTS store_inventory_product_id_idx ON store_inventory (product_id);
@@ -0,0 +1,29 @@
+-- Orders submitted by the stores, which buffer them while offline. A store
+-- submits each order once, identified by its client_order_id. Amounts are in
+-- the smallest unit of the currency.
+CREATE TABLE IF NOT EXISTS store_order (
+	id uuid PRIMARY KEY,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	updated_at timestamptz NOT NULL DEFAULT now(),
+	store_id uuid NOT NULL REFERENCES store (id) ON DELETE CASCADE,
+	client_order_id text NOT NULL,
+	payment_method_id uuid REFERENCES payment_method (id) ON DELETE SET NULL,
+	ordered_at timestamptz NOT NULL DEFAULT now(),
+	total bigint NOT NULL DEFAULT 0,
+	UNIQUE (store_id, client_order_id)
+);
+
+CREATE INDEX IF NOT EXISTS store_order_ordered_at_idx ON store_order (ordered_at);
+
+CREATE TABLE IF NOT EXISTS order_item (
+	id uuid PRIMARY KEY,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	order_id uuid NOT NULL REFERENCES store_order (id) ON DELETE CASCADE,
+	product_id uuid REFERENCES product (id) ON DELETE SET NULL,
+	slot integer,
+	quantity integer NOT NULL CHECK (quantity > 0),
+	unit_price bigint NOT NULL DEFAULT 0 CHECK (unit_price >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS order_item_order_id_idx ON order_item (order_id);
+CREATE INDEX IF NOT EXISTS order_item_product_id_idx ON order_item (product_id);
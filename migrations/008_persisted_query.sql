@@ -0,0 +1,7 @@
+-- Persisted queries by the hex encoded sha256 hash of their text, shared by
+-- every instance of the api. In allow-list mode only these queries execute.
+CREATE TABLE IF NOT EXISTS persisted_query (
+	hash text PRIMARY KEY,
+	query text NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now()
+);
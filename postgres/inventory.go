@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+)
+
+// scanInventorySlot copies the columns of a store_inventory row into an InventorySlot
+func scanInventorySlot(row scanner) (InventorySlot, error) {
+	var r InventorySlot
+	err := row.Scan(
+		&r.ID,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+		&r.StoreID,
+		&r.ProductID,
+		&r.Slot,
+		&r.Capacity,
+		&r.Quantity,
+		&r.Price,
+	)
+	return r, err
+}
+
+// queryInventory runs a query returning store_inventory rows
+func (d *Db) queryInventory(ctx context.Context, method string, query string, args ...interface{}) ([]InventorySlot, error) {
+	slots := []InventorySlot{}
+	rows, err := d.QueryContext(ctx, query, args...)
+	if err != nil {
+		return slots, fmt.Errorf("%s Query Err: %+v", method, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		r, err := scanInventorySlot(rows)
+		if err != nil {
+			return slots, fmt.Errorf("Error scanning rows: %+v", err)
+		}
+		slots = append(slots, r)
+	}
+	return slots, nil
+}
+
+// GetStoreInventory returns the slots of the given stores ordered by slot number
+func (d *Db) GetStoreInventory(ctx context.Context, storeIDs []uuid.UUID) ([]InventorySlot, error) {
+	return d.queryInventory(ctx, "GetStoreInventory",
+		`SELECT * FROM store_inventory WHERE store_id = ANY($1) ORDER BY store_id, slot`, pq.Array(storeIDs))
+}
+
+// ListLowStockSlots returns the stocked slots filled at or below ratio of their
+// capacity, emptiest first. Invalid vendorID and storeID do not narrow down
+// the slots
+func (d *Db) ListLowStockSlots(ctx context.Context, vendorID uuid.NullUUID, storeID uuid.NullUUID, ratio float64) ([]InventorySlot, error) {
+	if ratio < 0 || ratio > 1 {
+		return nil, fmt.Errorf("ratio must be between 0 and 1")
+	}
+	var w where
+	w.add("store_inventory.product_id IS NOT NULL")
+	w.add("store_inventory.quantity <= store_inventory.capacity * $%d", ratio)
+	if vendorID.Valid {
+		w.add("store.vendor_id = $%d", vendorID.UUID)
+	}
+	if storeID.Valid {
+		w.add("store_inventory.store_id = $%d", storeID.UUID)
+	}
+	return d.queryInventory(ctx, "ListLowStockSlots", `SELECT store_inventory.* FROM store_inventory
+		JOIN store ON store.id = store_inventory.store_id`+w.String()+`
+		ORDER BY store_inventory.quantity::float / NULLIF(store_inventory.capacity, 0) NULLS FIRST,
+			store_inventory.store_id, store_inventory.slot`, w.args...)
+}
+
+// RefillStore applies refills to the slots of a store and sets its last_refill
+// in one transaction, returning the store
+func (d *Db) RefillStore(ctx context.Context, storeID uuid.UUID, refills []SlotRefill) (Store, error) {
+	for _, refill := range refills {
+		if refill.Quantity.Valid && refill.Quantity.Int64 < 0 || refill.Capacity.Valid && refill.Capacity.Int64 < 0 || refill.Price.Valid && refill.Price.Int64 < 0 {
+			return Store{}, fmt.Errorf("slot %d: quantity, capacity and price must not be negative", refill.Slot)
+		}
+	}
+
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return Store{}, fmt.Errorf("RefillStore Begin Err: %+v", err)
+	}
+
+	store, err := scanStore(tx.QueryRowContext(ctx, `UPDATE store SET last_refill = now(), updated_at = now() WHERE id = $1 RETURNING *`, storeID))
+	if err != nil {
+		tx.Rollback()
+		return store, rowErr("RefillStore", "store", storeID, err)
+	}
+
+	for _, refill := range refills {
+		var productVendorID uuid.NullUUID
+		if refill.ProductID.Valid {
+			err = tx.QueryRowContext(ctx, `SELECT vendor_id FROM product WHERE id = $1`, refill.ProductID.UUID).Scan(&productVendorID)
+			if err != nil {
+				tx.Rollback()
+				return store, rowErr("RefillStore", "product", refill.ProductID.UUID, err)
+			}
+		}
+		// Lock the slot so its capacity cannot change before the upsert
+		var capacity sql.NullInt64
+		err = tx.QueryRowContext(ctx, `SELECT capacity FROM store_inventory WHERE store_id = $1 AND slot = $2 FOR UPDATE`,
+			storeID, refill.Slot).Scan(&capacity)
+		if err != nil && err != sql.ErrNoRows {
+			tx.Rollback()
+			return store, fmt.Errorf("RefillStore slot %d Err: %+v", refill.Slot, err)
+		}
+		if err = checkRefill(refill, store.VendorID, productVendorID, capacity); err != nil {
+			tx.Rollback()
+			return store, err
+		}
+
+		id, err := uuid.NewV4()
+		if err != nil {
+			tx.Rollback()
+			return store, err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO store_inventory
+				(id, created_at, updated_at, store_id, slot, product_id, capacity, price, quantity)
+			VALUES ($1, now(), now(), $2, $3, $4, COALESCE($5, 0), COALESCE($6, 0), COALESCE($7, $5, 0))
+			ON CONFLICT (store_id, slot) DO UPDATE SET
+				product_id = COALESCE($4, store_inventory.product_id),
+				capacity = COALESCE($5, store_inventory.capacity),
+				price = COALESCE($6, store_inventory.price),
+				quantity = COALESCE($7, $5, store_inventory.capacity),
+				updated_at = now()`,
+			id, storeID, refill.Slot, refill.ProductID, refill.Capacity, refill.Price, refill.Quantity)
+		if err != nil {
+			tx.Rollback()
+			return store, fmt.Errorf("RefillStore slot %d Err: %+v", refill.Slot, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return store, fmt.Errorf("RefillStore Commit Err: %+v", err)
+	}
+	return store, nil
+}
+
+// checkRefill checks a refill of RefillStore against the store it refills.
+// The product must be sold by the vendor of the store, and the quantity must
+// fit in the capacity of the slot, which is invalid when the slot does not
+// exist yet
+func checkRefill(refill SlotRefill, storeVendorID uuid.NullUUID, productVendorID uuid.NullUUID, capacity sql.NullInt64) error {
+	if refill.ProductID.Valid && productVendorID != storeVendorID {
+		return fmt.Errorf("slot %d: product %s is not sold by the vendor of the store", refill.Slot, refill.ProductID.UUID)
+	}
+	if refill.Capacity.Valid {
+		capacity = refill.Capacity
+	}
+	if !refill.Quantity.Valid || refill.Quantity.Int64 <= capacity.Int64 {
+		return nil
+	}
+	if !capacity.Valid {
+		return fmt.Errorf("slot %d: a new slot needs a capacity of at least its quantity %d", refill.Slot, refill.Quantity.Int64)
+	}
+	return fmt.Errorf("slot %d: quantity %d exceeds the capacity %d of the slot", refill.Slot, refill.Quantity.Int64, capacity.Int64)
+}
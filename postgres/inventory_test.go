@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"database/sql"
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestCheckRefill(t *testing.T) {
+	vendor := uuid.NullUUID{UUID: testID, Valid: true}
+	otherVendor := uuid.NullUUID{UUID: uuid.Must(uuid.FromString("6ba7b811-9dad-11d1-80b4-00c04fd430c8")), Valid: true}
+	product := uuid.NullUUID{UUID: uuid.Must(uuid.FromString("6ba7b812-9dad-11d1-80b4-00c04fd430c8")), Valid: true}
+	n := func(i int64) sql.NullInt64 { return sql.NullInt64{Int64: i, Valid: true} }
+	tests := []struct {
+		name            string
+		refill          SlotRefill
+		productVendorID uuid.NullUUID
+		capacity        sql.NullInt64
+		wantErr         bool
+	}{
+		{"product of the vendor", SlotRefill{Slot: 1, ProductID: product, Capacity: n(10), Quantity: n(10)}, vendor, sql.NullInt64{}, false},
+		{"product of another vendor", SlotRefill{Slot: 1, ProductID: product, Capacity: n(10)}, otherVendor, sql.NullInt64{}, true},
+		{"product without a vendor", SlotRefill{Slot: 1, ProductID: product, Capacity: n(10)}, uuid.NullUUID{}, sql.NullInt64{}, true},
+		{"new slot without a quantity", SlotRefill{Slot: 1}, uuid.NullUUID{}, sql.NullInt64{}, false},
+		{"new slot with a quantity but no capacity", SlotRefill{Slot: 1, Quantity: n(5)}, uuid.NullUUID{}, sql.NullInt64{}, true},
+		{"new empty slot without a capacity", SlotRefill{Slot: 1, Quantity: n(0)}, uuid.NullUUID{}, sql.NullInt64{}, false},
+		{"quantity within the slot", SlotRefill{Slot: 1, Quantity: n(8)}, uuid.NullUUID{}, n(8), false},
+		{"quantity over the slot", SlotRefill{Slot: 1, Quantity: n(9)}, uuid.NullUUID{}, n(8), true},
+		{"quantity within the new capacity", SlotRefill{Slot: 1, Quantity: n(12), Capacity: n(12)}, uuid.NullUUID{}, n(8), false},
+		{"quantity over the new capacity", SlotRefill{Slot: 1, Quantity: n(8), Capacity: n(6)}, uuid.NullUUID{}, n(8), true},
+		{"new capacity fills the slot", SlotRefill{Slot: 1, Capacity: n(6)}, uuid.NullUUID{}, n(8), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkRefill(tt.refill, vendor, tt.productVendorID, tt.capacity)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkRefill(%+v) error = %v, wantErr %v", tt.refill, err, tt.wantErr)
+			}
+		})
+	}
+}
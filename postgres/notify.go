@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Channels our triggers notify with the id of the row that changed, see
+// migrations/003_notify.sql
+const (
+	StoreChannel  = "store_changed"
+	VendorChannel = "vendor_changed"
+)
+
+// Notification announces a change to the row ID on Channel
+type Notification struct {
+	Channel string
+	ID      uuid.UUID
+}
+
+// Listen waits on channels over a dedicated connection and delivers their
+// notifications until ctx is done. Notifications sent while the connection
+// is being re-established are lost
+func (d *Db) Listen(ctx context.Context, channels ...string) (<-chan Notification, error) {
+	listener := pq.NewListener(d.connString, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			fmt.Printf("Listen Err: %+v\n", err)
+		}
+	})
+	for _, channel := range channels {
+		if err := listener.Listen(channel); err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("Listen %s Err: %+v", channel, err)
+		}
+	}
+
+	notifications := make(chan Notification)
+	go func() {
+		defer close(notifications)
+		defer listener.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case n := <-listener.Notify:
+				// A nil notification tells us the connection was re-established
+				if n == nil {
+					continue
+				}
+				id, err := uuid.FromString(n.Extra)
+				if err != nil {
+					fmt.Printf("Listen %s Err: invalid id %q\n", n.Channel, n.Extra)
+					continue
+				}
+				select {
+				case notifications <- Notification{Channel: n.Channel, ID: id}:
+				case <-ctx.Done():
+					return
+				}
+			case <-time.After(90 * time.Second):
+				// Make sure a silently dropped connection gets noticed
+				go listener.Ping()
+			}
+		}
+	}()
+	return notifications, nil
+}
+
+// ListStoresOnlineSince returns the stores that were last online after since
+func (d *Db) ListStoresOnlineSince(ctx context.Context, since time.Time) ([]Store, error) {
+	stores := []Store{}
+	rows, err := d.QueryContext(ctx, `SELECT * FROM store WHERE last_online_at > $1`, since)
+	if err != nil {
+		return stores, fmt.Errorf("ListStoresOnlineSince Query Err: %+v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		r, err := scanStore(rows)
+		if err != nil {
+			return stores, fmt.Errorf("Error scanning rows: %+v", err)
+		}
+		stores = append(stores, r)
+	}
+	return stores, nil
+}
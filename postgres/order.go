@@ -0,0 +1,208 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+)
+
+// scanOrder copies the columns of a store_order row into an Order
+func scanOrder(row scanner) (Order, error) {
+	var r Order
+	err := row.Scan(
+		&r.ID,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+		&r.StoreID,
+		&r.ClientOrderID,
+		&r.PaymentMethodID,
+		&r.OrderedAt,
+		&r.Total,
+	)
+	return r, err
+}
+
+// scanOrderItem copies the columns of an order_item row into an OrderItem
+func scanOrderItem(row scanner) (OrderItem, error) {
+	var r OrderItem
+	err := row.Scan(
+		&r.ID,
+		&r.CreatedAt,
+		&r.OrderID,
+		&r.ProductID,
+		&r.Slot,
+		&r.Quantity,
+		&r.UnitPrice,
+	)
+	return r, err
+}
+
+// GetOrderItems returns the items of the given orders
+func (d *Db) GetOrderItems(ctx context.Context, orderIDs []uuid.UUID) ([]OrderItem, error) {
+	items := []OrderItem{}
+	rows, err := d.QueryContext(ctx, `SELECT * FROM order_item WHERE order_id = ANY($1) ORDER BY order_id, created_at, id`, pq.Array(orderIDs))
+	if err != nil {
+		return items, fmt.Errorf("GetOrderItems Query Err: %+v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		r, err := scanOrderItem(rows)
+		if err != nil {
+			return items, fmt.Errorf("Error scanning rows: %+v", err)
+		}
+		items = append(items, r)
+	}
+	return items, nil
+}
+
+// SubmitOrders records the orders buffered by a store in one transaction and
+// returns them in the same order. Orders the store already submitted are
+// returned as they were recorded, so a batch can safely be submitted again.
+// The items of new orders are taken out of the inventory of the store, and
+// the unsubmitted_order_count of the store is decreased by the number of new
+// orders, as the store may still buffer orders of later batches
+func (d *Db) SubmitOrders(ctx context.Context, storeID uuid.UUID, submissions []OrderSubmission) ([]Order, error) {
+	for i, submission := range submissions {
+		if submission.ClientOrderID == "" {
+			return nil, fmt.Errorf("orders[%d]: client_order_id is required", i)
+		}
+		for j, item := range submission.Items {
+			if item.Quantity <= 0 {
+				return nil, fmt.Errorf("orders[%d].items[%d]: quantity must be positive", i, j)
+			}
+			if item.UnitPrice.Valid && item.UnitPrice.Int64 < 0 {
+				return nil, fmt.Errorf("orders[%d].items[%d]: unit_price must not be negative", i, j)
+			}
+		}
+	}
+
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("SubmitOrders Begin Err: %+v", err)
+	}
+
+	// Lock the store so concurrent batches of a store apply one after the other
+	err = tx.QueryRowContext(ctx, `SELECT id FROM store WHERE id = $1 FOR UPDATE`, storeID).Scan(&storeID)
+	if err != nil {
+		tx.Rollback()
+		return nil, rowErr("SubmitOrders", "store", storeID, err)
+	}
+
+	orders := make([]Order, 0, len(submissions))
+	submitted := 0
+	for _, submission := range submissions {
+		order, inserted, err := submitOrder(ctx, tx, storeID, submission)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("SubmitOrders order %s Err: %+v", submission.ClientOrderID, err)
+		}
+		if inserted {
+			submitted++
+		}
+		orders = append(orders, order)
+	}
+
+	// Orders submitted before were already taken off the count
+	_, err = tx.ExecContext(ctx, `UPDATE store SET unsubmitted_order_count = GREATEST(unsubmitted_order_count - $2, 0), updated_at = now() WHERE id = $1`,
+		storeID, submitted)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("SubmitOrders Update Err: %+v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("SubmitOrders Commit Err: %+v", err)
+	}
+	return orders, nil
+}
+
+// submitOrder records a single order of SubmitOrders, or returns the recorded
+// order when the store already submitted it. inserted is false for the latter
+func submitOrder(ctx context.Context, tx *sql.Tx, storeID uuid.UUID, submission OrderSubmission) (order Order, inserted bool, err error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return Order{}, false, err
+	}
+	order, err = scanOrder(tx.QueryRowContext(ctx, `INSERT INTO store_order
+			(id, created_at, updated_at, store_id, client_order_id, payment_method_id, ordered_at)
+		VALUES ($1, now(), now(), $2, $3, $4, COALESCE($5, now()))
+		ON CONFLICT (store_id, client_order_id) DO NOTHING RETURNING *`,
+		id, storeID, submission.ClientOrderID, submission.PaymentMethodID, submission.OrderedAt))
+	if err == sql.ErrNoRows {
+		order, err = scanOrder(tx.QueryRowContext(ctx, `SELECT * FROM store_order WHERE store_id = $1 AND client_order_id = $2`,
+			storeID, submission.ClientOrderID))
+		return order, false, err
+	}
+	if err != nil {
+		return order, false, err
+	}
+
+	for _, item := range submission.Items {
+		var (
+			slotID    uuid.UUID
+			slot      sql.NullInt64
+			productID uuid.NullUUID
+			price     sql.NullInt64
+		)
+		err := tx.QueryRowContext(ctx, `SELECT id, slot, product_id, price FROM store_inventory
+			WHERE store_id = $1 AND ($3::integer IS NOT NULL AND slot = $3 OR $3::integer IS NULL AND product_id = $2::uuid)
+			ORDER BY quantity DESC LIMIT 1 FOR UPDATE`,
+			storeID, item.ProductID, item.Slot).Scan(&slotID, &slot, &productID, &price)
+		if err != nil && err != sql.ErrNoRows {
+			return order, false, err
+		}
+		if err == nil {
+			if err = checkOrderItem(item, productID); err != nil {
+				return order, false, err
+			}
+			// Take the item out of its slot, the store has sold it already so
+			// the quantity stops at zero instead of failing
+			_, err = tx.ExecContext(ctx, `UPDATE store_inventory SET quantity = GREATEST(quantity - $2, 0), updated_at = now() WHERE id = $1`,
+				slotID, item.Quantity)
+			if err != nil {
+				return order, false, err
+			}
+		}
+		if !item.Slot.Valid {
+			item.Slot = slot
+		}
+		if !item.ProductID.Valid {
+			item.ProductID = productID
+		}
+		if !item.UnitPrice.Valid {
+			item.UnitPrice = price
+		}
+
+		itemID, err := uuid.NewV4()
+		if err != nil {
+			return order, false, err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO order_item (id, created_at, order_id, product_id, slot, quantity, unit_price)
+			VALUES ($1, now(), $2, $3, $4, $5, $6)`,
+			itemID, order.ID, item.ProductID, item.Slot, item.Quantity, item.UnitPrice.Int64)
+		if err != nil {
+			return order, false, err
+		}
+		order.Total += item.Quantity * item.UnitPrice.Int64
+	}
+
+	order, err = scanOrder(tx.QueryRowContext(ctx, `UPDATE store_order SET total = $2 WHERE id = $1 RETURNING *`, order.ID, order.Total))
+	return order, true, err
+}
+
+// checkOrderItem checks that an item of an order names the product stocked in
+// the slot it is taken from, productID
+func checkOrderItem(item OrderItemSubmission, productID uuid.NullUUID) error {
+	if !item.ProductID.Valid || item.ProductID == productID {
+		return nil
+	}
+	if !productID.Valid {
+		return fmt.Errorf("slot %d holds no product, not product %s", item.Slot.Int64, item.ProductID.UUID)
+	}
+	return fmt.Errorf("slot %d holds product %s, not product %s", item.Slot.Int64, productID.UUID, item.ProductID.UUID)
+}
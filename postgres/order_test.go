@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"database/sql"
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestCheckOrderItem(t *testing.T) {
+	product := uuid.NullUUID{UUID: testID, Valid: true}
+	otherProduct := uuid.NullUUID{UUID: uuid.Must(uuid.FromString("6ba7b811-9dad-11d1-80b4-00c04fd430c8")), Valid: true}
+	slot := sql.NullInt64{Int64: 3, Valid: true}
+	tests := []struct {
+		name      string
+		item      OrderItemSubmission
+		productID uuid.NullUUID
+		wantErr   bool
+	}{
+		{"product of the slot", OrderItemSubmission{ProductID: product, Slot: slot, Quantity: 1}, product, false},
+		{"slot only", OrderItemSubmission{Slot: slot, Quantity: 1}, product, false},
+		{"product only", OrderItemSubmission{ProductID: product, Quantity: 1}, product, false},
+		{"product of another slot", OrderItemSubmission{ProductID: otherProduct, Slot: slot, Quantity: 1}, product, true},
+		{"slot without a product", OrderItemSubmission{ProductID: product, Slot: slot, Quantity: 1}, uuid.NullUUID{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkOrderItem(tt.item, tt.productID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkOrderItem(%+v, %v) error = %v, wantErr %v", tt.item, tt.productID, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// DefaultPageSize is used when a Page does not ask for a number of rows
+const DefaultPageSize = 20
+
+// MaxPageSize is the largest number of rows a single Page may return
+const MaxPageSize = 100
+
+// Cursor points at a row by the value of the column the page is ordered by
+// and the row's id, which breaks ties between equal values
+type Cursor struct {
+	OrderBy string    `json:"o"`
+	Value   string    `json:"v"`
+	ID      uuid.UUID `json:"id"`
+}
+
+// Page requests the rows following After in keyset order
+type Page struct {
+	// OrderBy is the column rows are ordered by, it must be sortable on the table
+	OrderBy string
+	Desc    bool
+	First   int
+	After   *Cursor
+}
+
+// where collects the conditions of a WHERE clause and their arguments
+type where struct {
+	conds []string
+	args  []interface{}
+}
+
+// add appends a condition, each %d in cond is replaced by the placeholder
+// number of the matching arg
+func (w *where) add(cond string, args ...interface{}) {
+	placeholders := make([]interface{}, len(args))
+	for i, arg := range args {
+		w.args = append(w.args, arg)
+		placeholders[i] = len(w.args)
+	}
+	w.conds = append(w.conds, fmt.Sprintf(cond, placeholders...))
+}
+
+func (w *where) String() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conds, " AND ")
+}
+
+// limit returns the number of rows to return, validated against MaxPageSize
+func (page Page) limit() (int, error) {
+	switch {
+	case page.First == 0:
+		return DefaultPageSize, nil
+	case page.First < 0 || page.First > MaxPageSize:
+		return 0, fmt.Errorf("first must be between 1 and %d", MaxPageSize)
+	}
+	return page.First, nil
+}
+
+// clause adds the keyset condition of page to w and returns the ORDER BY and
+// LIMIT clauses. One extra row is fetched to tell whether there is a next page
+func (page Page) clause(w *where, sortable ...string) (string, int, error) {
+	valid := false
+	for _, column := range sortable {
+		valid = valid || column == page.OrderBy
+	}
+	if !valid {
+		return "", 0, fmt.Errorf("cannot order by %q", page.OrderBy)
+	}
+	limit, err := page.limit()
+	if err != nil {
+		return "", 0, err
+	}
+
+	direction, cmp := "ASC", ">"
+	if page.Desc {
+		direction, cmp = "DESC", "<"
+	}
+	if page.After != nil {
+		if page.After.OrderBy != page.OrderBy {
+			return "", 0, fmt.Errorf("cursor was created for a different order")
+		}
+		w.add(fmt.Sprintf("(%s, id) %s ($%%d, $%%d)", page.OrderBy, cmp), page.After.Value, page.After.ID)
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %d", page.OrderBy, direction, direction, limit+1), limit, nil
+}
+
+// containsPattern returns an ILIKE pattern matching s anywhere in a value
+func containsPattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+var testID = uuid.Must(uuid.FromString("6ba7b810-9dad-11d1-80b4-00c04fd430c8"))
+
+func TestPageClause(t *testing.T) {
+	after := &Cursor{OrderBy: "name", Value: "Coffee", ID: testID}
+	tests := []struct {
+		name      string
+		page      Page
+		wantOrder string
+		wantLimit int
+		wantCond  string
+		wantArgs  []interface{}
+		wantErr   bool
+	}{
+		{"first page", Page{OrderBy: "name"}, " ORDER BY name ASC, id ASC LIMIT 21", DefaultPageSize, "", nil, false},
+		{"descending", Page{OrderBy: "created_at", Desc: true, First: 5}, " ORDER BY created_at DESC, id DESC LIMIT 6", 5, "", nil, false},
+		{"largest page", Page{OrderBy: "name", First: MaxPageSize}, " ORDER BY name ASC, id ASC LIMIT 101", MaxPageSize, "", nil, false},
+		{"after a cursor", Page{OrderBy: "name", First: 2, After: after}, " ORDER BY name ASC, id ASC LIMIT 3", 2, " WHERE (name, id) > ($1, $2)", []interface{}{"Coffee", testID}, false},
+		{"descending after a cursor", Page{OrderBy: "name", Desc: true, After: after}, " ORDER BY name DESC, id DESC LIMIT 21", DefaultPageSize, " WHERE (name, id) < ($1, $2)", []interface{}{"Coffee", testID}, false},
+		{"cursor of another order", Page{OrderBy: "created_at", After: after}, "", 0, "", nil, true},
+		{"unsortable column", Page{OrderBy: "password"}, "", 0, "", nil, true},
+		{"negative size", Page{OrderBy: "name", First: -1}, "", 0, "", nil, true},
+		{"page too large", Page{OrderBy: "name", First: MaxPageSize + 1}, "", 0, "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w where
+			order, limit, err := tt.page.clause(&w, "name", "created_at")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("clause() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if order != tt.wantOrder || limit != tt.wantLimit {
+				t.Errorf("clause() = %q, %d, want %q, %d", order, limit, tt.wantOrder, tt.wantLimit)
+			}
+			if w.String() != tt.wantCond || !reflect.DeepEqual(w.args, tt.wantArgs) {
+				t.Errorf("clause() where = %q %v, want %q %v", w.String(), w.args, tt.wantCond, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestWhereAdd(t *testing.T) {
+	var w where
+	w.add("vendor_id = $%d", testID)
+	w.add("name ILIKE $%d OR code ILIKE $%d", "%a%", "%b%")
+	want := " WHERE vendor_id = $1 AND name ILIKE $2 OR code ILIKE $3"
+	if w.String() != want {
+		t.Errorf("String() = %q, want %q", w.String(), want)
+	}
+	if wantArgs := []interface{}{testID, "%a%", "%b%"}; !reflect.DeepEqual(w.args, wantArgs) {
+		t.Errorf("args = %v, want %v", w.args, wantArgs)
+	}
+}
+
+func TestContainsPattern(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"coffee", "%coffee%"},
+		{"100%", `%100\%%`},
+		{"a_b", `%a\_b%`},
+		{`c:\`, `%c:\\%`},
+		{"", "%%"},
+	}
+	for _, tt := range tests {
+		if got := containsPattern(tt.s); got != tt.want {
+			t.Errorf("containsPattern(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+)
+
+// scanPaymentMethod copies the columns of a payment method row into a
+// PaymentMethod, any extra columns selected after them are copied into extra
+func scanPaymentMethod(row scanner, extra ...interface{}) (PaymentMethod, error) {
+	var r PaymentMethod
+	err := row.Scan(append([]interface{}{
+		&r.ID,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+		&r.MongoID,
+		&r.Code,
+		&r.Name,
+		&r.Module,
+		&r.ModuleChannel,
+		&r.OrderIndex,
+	}, extra...)...)
+	return r, err
+}
+
+// ListPaymentMethods returns every payment method ordered by order_index
+func (d *Db) ListPaymentMethods(ctx context.Context) ([]PaymentMethod, error) {
+	paymentMethods := []PaymentMethod{}
+	rows, err := d.QueryContext(ctx, `SELECT * FROM payment_method ORDER BY order_index, name, id`)
+	if err != nil {
+		return paymentMethods, fmt.Errorf("ListPaymentMethods Query Err: %+v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		r, err := scanPaymentMethod(rows)
+		if err != nil {
+			return paymentMethods, fmt.Errorf("Error scanning rows: %+v", err)
+		}
+		paymentMethods = append(paymentMethods, r)
+	}
+	return paymentMethods, nil
+}
+
+// GetPaymentMethods returns the payment methods with the given ids
+func (d *Db) GetPaymentMethods(ctx context.Context, paymentMethodIDs []uuid.UUID) ([]PaymentMethod, error) {
+	paymentMethods := []PaymentMethod{}
+	rows, err := d.QueryContext(ctx, `SELECT * FROM payment_method WHERE id = ANY($1)`, pq.Array(paymentMethodIDs))
+	if err != nil {
+		return paymentMethods, fmt.Errorf("GetPaymentMethods Query Err: %+v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		r, err := scanPaymentMethod(rows)
+		if err != nil {
+			return paymentMethods, fmt.Errorf("Error scanning rows: %+v", err)
+		}
+		paymentMethods = append(paymentMethods, r)
+	}
+	return paymentMethods, nil
+}
+
+// GetStorePaymentMethods returns the payment methods accepted by the given
+// stores ordered by order_index
+func (d *Db) GetStorePaymentMethods(ctx context.Context, storeIDs []uuid.UUID) ([]StorePaymentMethod, error) {
+	paymentMethods := []StorePaymentMethod{}
+	rows, err := d.QueryContext(ctx, `SELECT payment_method.*, store_payment_method.store_id FROM payment_method
+		JOIN store_payment_method ON store_payment_method.payment_method_id = payment_method.id
+		WHERE store_payment_method.store_id = ANY($1)
+		ORDER BY payment_method.order_index, payment_method.name, payment_method.id`, pq.Array(storeIDs))
+	if err != nil {
+		return paymentMethods, fmt.Errorf("GetStorePaymentMethods Query Err: %+v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var r StorePaymentMethod
+		r.PaymentMethod, err = scanPaymentMethod(rows, &r.StoreID)
+		if err != nil {
+			return paymentMethods, fmt.Errorf("Error scanning rows: %+v", err)
+		}
+		paymentMethods = append(paymentMethods, r)
+	}
+	return paymentMethods, nil
+}
+
+// CreatePaymentMethod inserts a payment method with the given columns and returns it
+func (d *Db) CreatePaymentMethod(ctx context.Context, values map[string]interface{}) (PaymentMethod, error) {
+	row, err := insertRow(ctx, d, "payment_method", values)
+	if err != nil {
+		return PaymentMethod{}, err
+	}
+	paymentMethod, err := scanPaymentMethod(row)
+	if err != nil {
+		return paymentMethod, fmt.Errorf("CreatePaymentMethod Query Err: %+v", err)
+	}
+	return paymentMethod, nil
+}
+
+// EditPaymentMethod updates only the given columns of a payment method and returns the updated row
+func (d *Db) EditPaymentMethod(ctx context.Context, paymentMethodID uuid.UUID, values map[string]interface{}) (PaymentMethod, error) {
+	paymentMethod, err := scanPaymentMethod(updateRow(ctx, d, "payment_method", paymentMethodID, values))
+	if err != nil {
+		return paymentMethod, rowErr("EditPaymentMethod", "payment_method", paymentMethodID, err)
+	}
+	return paymentMethod, nil
+}
+
+// DeletePaymentMethod deletes a payment method, which is no longer accepted
+// by any store, and returns the deleted row
+func (d *Db) DeletePaymentMethod(ctx context.Context, paymentMethodID uuid.UUID) (PaymentMethod, error) {
+	paymentMethod, err := scanPaymentMethod(deleteRow(ctx, d, "payment_method", paymentMethodID))
+	if err != nil {
+		return paymentMethod, rowErr("DeletePaymentMethod", "payment_method", paymentMethodID, err)
+	}
+	return paymentMethod, nil
+}
+
+// SetStorePaymentMethods replaces the payment methods accepted by a store in
+// one transaction and returns the store
+func (d *Db) SetStorePaymentMethods(ctx context.Context, storeID uuid.UUID, paymentMethodIDs []uuid.UUID) (Store, error) {
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return Store{}, fmt.Errorf("SetStorePaymentMethods Begin Err: %+v", err)
+	}
+
+	// Lock the store so concurrent calls apply one after the other
+	store, err := scanStore(tx.QueryRowContext(ctx, `SELECT * FROM store WHERE id = $1 FOR UPDATE`, storeID))
+	if err != nil {
+		tx.Rollback()
+		return store, rowErr("SetStorePaymentMethods", "store", storeID, err)
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM store_payment_method WHERE store_id = $1`, storeID); err != nil {
+		tx.Rollback()
+		return store, fmt.Errorf("SetStorePaymentMethods Delete Err: %+v", err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO store_payment_method (store_id, payment_method_id)
+		SELECT $1, id FROM unnest($2::uuid[]) AS id ON CONFLICT DO NOTHING`, storeID, pq.Array(paymentMethodIDs))
+	if err != nil {
+		tx.Rollback()
+		return store, fmt.Errorf("SetStorePaymentMethods Insert Err: %+v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return store, fmt.Errorf("SetStorePaymentMethods Commit Err: %+v", err)
+	}
+	return store, nil
+}
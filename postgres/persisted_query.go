@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// GetPersistedQuery returns the query persisted with the given hash, ok is
+// false when there is none
+func (d *Db) GetPersistedQuery(ctx context.Context, hash string) (query string, ok bool, err error) {
+	err = d.QueryRowContext(ctx, `SELECT query FROM persisted_query WHERE hash = $1`, hash).Scan(&query)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("GetPersistedQuery Query Err: %+v", err)
+	}
+	return query, true, nil
+}
+
+// ListPersistedQueries returns every persisted query keyed by its hash
+func (d *Db) ListPersistedQueries(ctx context.Context) (map[string]string, error) {
+	queries := map[string]string{}
+	rows, err := d.QueryContext(ctx, `SELECT hash, query FROM persisted_query`)
+	if err != nil {
+		return queries, fmt.Errorf("ListPersistedQueries Query Err: %+v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash, query string
+		if err := rows.Scan(&hash, &query); err != nil {
+			return queries, fmt.Errorf("Error scanning rows: %+v", err)
+		}
+		queries[hash] = query
+	}
+	return queries, nil
+}
+
+// SavePersistedQuery persists a query with its hash, a query persisted
+// already is left as it is
+func (d *Db) SavePersistedQuery(ctx context.Context, hash string, query string) error {
+	_, err := d.ExecContext(ctx, `INSERT INTO persisted_query (hash, query) VALUES ($1, $2) ON CONFLICT (hash) DO NOTHING`, hash, query)
+	if err != nil {
+		return fmt.Errorf("SavePersistedQuery Query Err: %+v", err)
+	}
+	return nil
+}
@@ -1,8 +1,11 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 
 	// postgres driver
 
@@ -14,6 +17,8 @@ import (
 // Db is our database struct used for interacting with the database
 type Db struct {
 	*sql.DB
+	// connString opens the dedicated connections Listen waits on
+	connString string
 }
 
 // New makes a new database using the connection string and
@@ -30,7 +35,7 @@ func New(connString string) (*Db, error) {
 		return nil, err
 	}
 
-	return &Db{db}, nil
+	return &Db{DB: db, connString: connString}, nil
 }
 
 // ConnString returns a connection string based on the parameters it's given
@@ -42,37 +47,132 @@ func ConnString(host string, port int, user string, password string, dbName stri
 	)
 }
 
-func (d *Db) GetVendorProducts(vendorIDs []uuid.UUID) ([]Product, error) {
-	// Create Vendor struct for holding each row's data
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProduct copies the columns of a product row into a Product, any extra
+// columns selected after them are copied into extra
+func scanProduct(row scanner, extra ...interface{}) (Product, error) {
 	var r Product
-	// Create slice of Users for our response
+	err := row.Scan(append([]interface{}{
+		&r.ID,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+		&r.MongoID,
+		&r.Photo,
+		&r.Code,
+		&r.IsVirtualProduct,
+		&r.Barcode,
+		&r.Descriptions,
+		&r.BrandNames,
+		&r.Names,
+		&r.OptionalData,
+		&r.VendorID,
+		&r.SupplierID,
+	}, extra...)...)
+	return r, err
+}
+
+// scanStore copies the columns of a store row into a Store
+func scanStore(row scanner) (Store, error) {
+	var r Store
+	err := row.Scan(
+		&r.ID,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+		&r.MongoID,
+		&r.Code,
+		&r.Name,
+		&r.Model,
+		&r.Address,
+		&r.LastOnlineAt,
+		&r.LastGet,
+		&r.LastSync,
+		&r.LastRefill,
+		&r.LastReset,
+		&r.UnsubmittedOrderCount,
+		&r.VendorID,
+	)
+	return r, err
+}
+
+// scanVendor copies the columns of a vendor row into a Vendor
+func scanVendor(row scanner) (Vendor, error) {
+	var r Vendor
+	err := row.Scan(
+		&r.ID,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+		&r.MongoID,
+		&r.Name,
+		&r.Description,
+	)
+	return r, err
+}
+
+// JSONMerge is the value of a jsonb column whose keys are merged into the
+// keys the row already has on update, instead of replacing the column, after
+// which the Remove keys are deleted. Value is written as is on insert
+type JSONMerge struct {
+	Value  interface{}
+	Remove []string
+}
+
+// columnValue returns the value written to a column on insert
+func columnValue(value interface{}) interface{} {
+	if merge, ok := value.(JSONMerge); ok {
+		return merge.Value
+	}
+	return value
+}
+
+// setClause builds the SET clause of a partial UPDATE from the given columns
+// and always bumps updated_at. Placeholders are numbered from $1, so the
+// caller's own arguments start at len(args)+1
+func setClause(values map[string]interface{}) (string, []interface{}) {
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	sets := make([]string, 0, len(columns)+1)
+	args := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		merge, ok := values[column].(JSONMerge)
+		if !ok {
+			args = append(args, values[column])
+			sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+			continue
+		}
+		args = append(args, merge.Value)
+		set := fmt.Sprintf("COALESCE(%s, '{}'::jsonb) || $%d::jsonb", column, len(args))
+		if len(merge.Remove) > 0 {
+			args = append(args, pq.Array(merge.Remove))
+			set = fmt.Sprintf("(%s) - $%d::text[]", set, len(args))
+		}
+		sets = append(sets, fmt.Sprintf("%s = %s", column, set))
+	}
+	sets = append(sets, "updated_at = now()")
+	return strings.Join(sets, ", "), args
+}
+
+func (d *Db) GetVendorProducts(ctx context.Context, vendorIDs []uuid.UUID) ([]Product, error) {
+	// Create slice of Products for our response
 	products := []Product{}
-	// Make query with our stmt, passing in phoneDeviceID argument
-	//rows, err := d.Query("SELECT vendor.*, array_to_json(array_agg(row_to_json(product.*))) AS products FROM vendor JOIN product ON product.vendor_id = vendor.id GROUP BY vendor.id WHERE vendor.id IN $1", vendorIDs)
-	rows, err := d.Query(`SELECT product.* FROM product JOIN vendor ON product.vendor_id = vendor.id WHERE vendor.id = ANY($1)`, pq.Array(vendorIDs))
+	rows, err := d.QueryContext(ctx, `SELECT product.* FROM product JOIN vendor ON product.vendor_id = vendor.id WHERE vendor.id = ANY($1)`, pq.Array(vendorIDs))
 
 	if err != nil {
 		return products, fmt.Errorf("GetVendorProducts Query Err: %+v", err)
 	}
 
-	// Copy the columns from row into the values pointed at by r (Product)
+	defer rows.Close()
+
+	// Copy the columns from each row into a Product
 	for rows.Next() {
-		err = rows.Scan(
-			&r.ID,
-			&r.CreatedAt,
-			&r.UpdatedAt,
-			&r.MongoID,
-			&r.Photo,
-			&r.Code,
-			&r.IsVirtualProduct,
-			&r.Barcode,
-			&r.Descriptions,
-			&r.BrandNames,
-			&r.Names,
-			&r.OptionalData,
-			&r.VendorID,
-			&r.SupplierID,
-		)
+		r, err := scanProduct(rows)
 		if err != nil {
 			return products, fmt.Errorf("Error scanning rows: %+v", err)
 		}
@@ -81,38 +181,20 @@ func (d *Db) GetVendorProducts(vendorIDs []uuid.UUID) ([]Product, error) {
 	return products, nil
 }
 
-func (d *Db) GetVendorStores(vendorIDs []uuid.UUID) ([]Store, error) {
-	// Create Store struct for holding each row's data
-	var r Store
+func (d *Db) GetVendorStores(ctx context.Context, vendorIDs []uuid.UUID) ([]Store, error) {
 	// Create slice of Stores for our response
 	stores := []Store{}
-	// Make query with our stmt, passing in phoneDeviceID argument
-	//rows, err := d.Query("SELECT vendor.*, array_to_json(array_agg(row_to_json(product.*))) AS products FROM vendor JOIN product ON product.vendor_id = vendor.id GROUP BY vendor.id WHERE vendor.id IN $1", vendorIDs)
-	rows, err := d.Query(`SELECT store.* FROM store JOIN vendor ON store.vendor_id = vendor.id WHERE vendor.id = ANY($1)`, pq.Array(vendorIDs))
+	rows, err := d.QueryContext(ctx, `SELECT store.* FROM store JOIN vendor ON store.vendor_id = vendor.id WHERE vendor.id = ANY($1)`, pq.Array(vendorIDs))
 
 	if err != nil {
 		return stores, fmt.Errorf("GetVendorStores Query Err: %+v", err)
 	}
 
-	// Copy the columns from row into the values pointed at by r (Store)
+	defer rows.Close()
+
+	// Copy the columns from each row into a Store
 	for rows.Next() {
-		err = rows.Scan(
-			&r.ID,
-			&r.CreatedAt,
-			&r.UpdatedAt,
-			&r.MongoID,
-			&r.Code,
-			&r.Name,
-			&r.Model,
-			&r.Address,
-			&r.LastOnlineAt,
-			&r.LastGet,
-			&r.LastSync,
-			&r.LastRefill,
-			&r.LastReset,
-			&r.UnsubmittedOrderCount,
-			&r.VendorID,
-		)
+		r, err := scanStore(rows)
 		if err != nil {
 			return stores, fmt.Errorf("Error scanning rows: %+v", err)
 		}
@@ -121,29 +203,20 @@ func (d *Db) GetVendorStores(vendorIDs []uuid.UUID) ([]Store, error) {
 	return stores, nil
 }
 
-func (d *Db) GetVendors(vendorIDs []uuid.UUID) ([]Vendor, error) {
-	// Create Vendor struct for holding each row's data
-	var r Vendor
-	// Create slice of Users for our response
+func (d *Db) GetVendors(ctx context.Context, vendorIDs []uuid.UUID) ([]Vendor, error) {
+	// Create slice of Vendors for our response
 	vendors := []Vendor{}
-	// Make query with our stmt, passing in phoneDeviceID argument
-	//rows, err := d.Query("SELECT vendor.*, array_to_json(array_agg(row_to_json(product.*))) AS products FROM vendor JOIN product ON product.vendor_id = vendor.id GROUP BY vendor.id WHERE vendor.id IN $1", vendorIDs)
-	rows, err := d.Query(`SELECT * FROM vendor WHERE vendor.id = ANY($1)`, pq.Array(vendorIDs))
+	rows, err := d.QueryContext(ctx, `SELECT * FROM vendor WHERE vendor.id = ANY($1)`, pq.Array(vendorIDs))
 
 	if err != nil {
 		return vendors, fmt.Errorf("GetVendors Query Err: %+v", err)
 	}
 
-	// Copy the columns from row into the values pointed at by r (Vendors)
+	defer rows.Close()
+
+	// Copy the columns from each row into a Vendor
 	for rows.Next() {
-		err = rows.Scan(
-			&r.ID,
-			&r.CreatedAt,
-			&r.UpdatedAt,
-			&r.MongoID,
-			&r.Name,
-			&r.Description,
-		)
+		r, err := scanVendor(rows)
 		if err != nil {
 			return vendors, fmt.Errorf("Error scanning rows: %+v", err)
 		}
@@ -152,15 +225,339 @@ func (d *Db) GetVendors(vendorIDs []uuid.UUID) ([]Vendor, error) {
 	return vendors, nil
 }
 
-func (d *Db) EditVendors(vendorID uuid.UUID) error {
-	// Make query with our stmt, passing in phoneDeviceID argument
-	//rows, err := d.Query("SELECT vendor.*, array_to_json(array_agg(row_to_json(product.*))) AS products FROM vendor JOIN product ON product.vendor_id = vendor.id GROUP BY vendor.id WHERE vendor.id IN $1", vendorIDs)
-	rows, err := d.Exec(`UPDATE vendor SET description = $1 WHERE id = $2 `, "testssssss", vendorID)
-	fmt.Println(rows.LastInsertId)
+// listPage counts the rows of table matching w and then queries the requested
+// page of them, scanning each row with scan. It returns the total count and
+// the number of rows the page is limited to
+func (d *Db) listPage(ctx context.Context, method string, table string, w where, page Page, sortable []string, scan func(row scanner) error) (int, int, error) {
+	// The total ignores the cursor, so it is counted before the page is applied
+	var total int
+	err := d.QueryRowContext(ctx, `SELECT count(*) FROM `+table+w.String(), w.args...).Scan(&total)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s Count Err: %+v", method, err)
+	}
+
+	order, limit, err := page.clause(&w, sortable...)
+	if err != nil {
+		return 0, 0, err
+	}
+	rows, err := d.QueryContext(ctx, `SELECT * FROM `+table+w.String()+order, w.args...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s Query Err: %+v", method, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return 0, 0, fmt.Errorf("Error scanning rows: %+v", err)
+		}
+	}
+	return total, limit, nil
+}
+
+// ListVendors returns a page of the vendors matching filter
+func (d *Db) ListVendors(ctx context.Context, filter VendorFilter, page Page) (VendorPage, error) {
+	result := VendorPage{Vendors: []Vendor{}}
+	var w where
+	if filter.NameContains != "" {
+		w.add("name ILIKE $%d", containsPattern(filter.NameContains))
+	}
+	if filter.CreatedAfter.Valid {
+		w.add("created_at >= $%d", filter.CreatedAfter.Time)
+	}
+	if filter.CreatedBefore.Valid {
+		w.add("created_at < $%d", filter.CreatedBefore.Time)
+	}
+
+	total, limit, err := d.listPage(ctx, "ListVendors", "vendor", w, page, []string{"created_at", "name"}, func(row scanner) error {
+		r, err := scanVendor(row)
+		result.Vendors = append(result.Vendors, r)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+	result.TotalCount = total
+	if len(result.Vendors) > limit {
+		result.Vendors = result.Vendors[:limit]
+		result.HasNextPage = true
+	}
+	return result, nil
+}
+
+// GetProducts returns the products with the given ids
+func (d *Db) GetProducts(ctx context.Context, productIDs []uuid.UUID) ([]Product, error) {
+	// Create slice of Products for our response
+	products := []Product{}
+	rows, err := d.QueryContext(ctx, `SELECT * FROM product WHERE id = ANY($1)`, pq.Array(productIDs))
+
+	if err != nil {
+		return products, fmt.Errorf("GetProducts Query Err: %+v", err)
+	}
+
+	defer rows.Close()
+
+	// Copy the columns from each row into a Product
+	for rows.Next() {
+		r, err := scanProduct(rows)
+		if err != nil {
+			return products, fmt.Errorf("Error scanning rows: %+v", err)
+		}
+		products = append(products, r)
+	}
+	return products, nil
+}
+
+// GetProductByBarcode returns the first product with the barcode, or nil if there is none
+func (d *Db) GetProductByBarcode(ctx context.Context, barcode string) (*Product, error) {
+	product, err := scanProduct(d.QueryRowContext(ctx, `SELECT * FROM product WHERE barcode = $1 ORDER BY created_at LIMIT 1`, barcode))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetProductByBarcode Query Err: %+v", err)
+	}
+	return &product, nil
+}
+
+// ListProducts returns a page of the products matching filter
+func (d *Db) ListProducts(ctx context.Context, filter ProductFilter, page Page) (ProductPage, error) {
+	result := ProductPage{Products: []Product{}}
+	var w where
+	if filter.VendorID.Valid {
+		w.add("vendor_id = $%d", filter.VendorID.UUID)
+	}
+	if filter.SupplierID.Valid {
+		w.add("supplier_id = $%d", filter.SupplierID.UUID)
+	}
+	if filter.IsVirtualProduct.Valid {
+		w.add("is_virtual_product = $%d", filter.IsVirtualProduct.Bool)
+	}
+	if filter.Code.Valid {
+		w.add("code = $%d", filter.Code.String)
+	}
+
+	total, limit, err := d.listPage(ctx, "ListProducts", "product", w, page, []string{"created_at", "updated_at"}, func(row scanner) error {
+		r, err := scanProduct(row)
+		result.Products = append(result.Products, r)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+	result.TotalCount = total
+	if len(result.Products) > limit {
+		result.Products = result.Products[:limit]
+		result.HasNextPage = true
+	}
+	return result, nil
+}
+
+// GetStores returns the stores with the given ids
+func (d *Db) GetStores(ctx context.Context, storeIDs []uuid.UUID) ([]Store, error) {
+	// Create slice of Stores for our response
+	stores := []Store{}
+	rows, err := d.QueryContext(ctx, `SELECT * FROM store WHERE id = ANY($1)`, pq.Array(storeIDs))
+
+	if err != nil {
+		return stores, fmt.Errorf("GetStores Query Err: %+v", err)
+	}
+
+	defer rows.Close()
+
+	// Copy the columns from each row into a Store
+	for rows.Next() {
+		r, err := scanStore(rows)
+		if err != nil {
+			return stores, fmt.Errorf("Error scanning rows: %+v", err)
+		}
+		stores = append(stores, r)
+	}
+	return stores, nil
+}
+
+// GetStoreByCode returns the store with the code, or nil if there is none
+func (d *Db) GetStoreByCode(ctx context.Context, code string) (*Store, error) {
+	store, err := scanStore(d.QueryRowContext(ctx, `SELECT * FROM store WHERE code = $1 ORDER BY created_at LIMIT 1`, code))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetStoreByCode Query Err: %+v", err)
+	}
+	return &store, nil
+}
+
+// ListStores returns a page of the stores matching filter
+func (d *Db) ListStores(ctx context.Context, filter StoreFilter, page Page) (StorePage, error) {
+	result := StorePage{Stores: []Store{}}
+	var w where
+	if filter.VendorID.Valid {
+		w.add("vendor_id = $%d", filter.VendorID.UUID)
+	}
+	if filter.Code.Valid {
+		w.add("code = $%d", filter.Code.String)
+	}
+
+	total, limit, err := d.listPage(ctx, "ListStores", "store", w, page, []string{"created_at", "updated_at"}, func(row scanner) error {
+		r, err := scanStore(row)
+		result.Stores = append(result.Stores, r)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+	result.TotalCount = total
+	if len(result.Stores) > limit {
+		result.Stores = result.Stores[:limit]
+		result.HasNextPage = true
+	}
+	return result, nil
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx so writes can run
+// standalone or as part of a transaction
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
 
+// insertRow inserts a new row with a generated id into table and returns it
+func insertRow(ctx context.Context, q queryer, table string, values map[string]interface{}) (*sql.Row, error) {
+	id, err := uuid.NewV4()
 	if err != nil {
-		return fmt.Errorf("GetVendors Query Err: %+v", err)
+		return nil, err
 	}
+	columns := []string{"id", "created_at", "updated_at"}
+	placeholders := []string{"$1", "now()", "now()"}
+	args := []interface{}{id}
+	for column, value := range values {
+		args = append(args, columnValue(value))
+		columns = append(columns, column)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s) RETURNING *`, table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	return q.QueryRowContext(ctx, query, args...), nil
+}
 
-	return nil
+// updateRow updates only the given columns of a row in table and returns it
+func updateRow(ctx context.Context, q queryer, table string, id uuid.UUID, values map[string]interface{}) *sql.Row {
+	set, args := setClause(values)
+	query := fmt.Sprintf(`UPDATE %s SET %s WHERE id = $%d RETURNING *`, table, set, len(args)+1)
+	return q.QueryRowContext(ctx, query, append(args, id)...)
+}
+
+// deleteRow deletes a row from table and returns it
+func deleteRow(ctx context.Context, q queryer, table string, id uuid.UUID) *sql.Row {
+	return q.QueryRowContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = $1 RETURNING *`, table), id)
+}
+
+// NotFoundError is returned when the row a statement is about does not exist
+type NotFoundError struct {
+	Table string
+	ID    uuid.UUID
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("%s %s not found", e.Table, e.ID)
+}
+
+// rowErr describes the error of a single row statement on table
+func rowErr(method string, table string, id uuid.UUID, err error) error {
+	if err == sql.ErrNoRows {
+		return NotFoundError{Table: table, ID: id}
+	}
+	return fmt.Errorf("%s Query Err: %+v", method, err)
+}
+
+// CreateVendor inserts a vendor with the given columns and returns it
+func (d *Db) CreateVendor(ctx context.Context, values map[string]interface{}) (Vendor, error) {
+	row, err := insertRow(ctx, d, "vendor", values)
+	if err != nil {
+		return Vendor{}, err
+	}
+	vendor, err := scanVendor(row)
+	if err != nil {
+		return vendor, fmt.Errorf("CreateVendor Query Err: %+v", err)
+	}
+	return vendor, nil
+}
+
+// EditVendor updates only the given columns of a vendor and returns the updated row
+func (d *Db) EditVendor(ctx context.Context, vendorID uuid.UUID, values map[string]interface{}) (Vendor, error) {
+	vendor, err := scanVendor(updateRow(ctx, d, "vendor", vendorID, values))
+	if err != nil {
+		return vendor, rowErr("EditVendor", "vendor", vendorID, err)
+	}
+	return vendor, nil
+}
+
+// DeleteVendor deletes a vendor and returns the deleted row
+func (d *Db) DeleteVendor(ctx context.Context, vendorID uuid.UUID) (Vendor, error) {
+	vendor, err := scanVendor(deleteRow(ctx, d, "vendor", vendorID))
+	if err != nil {
+		return vendor, rowErr("DeleteVendor", "vendor", vendorID, err)
+	}
+	return vendor, nil
+}
+
+// CreateProduct inserts a product with the given columns and returns it
+func (d *Db) CreateProduct(ctx context.Context, values map[string]interface{}) (Product, error) {
+	row, err := insertRow(ctx, d, "product", values)
+	if err != nil {
+		return Product{}, err
+	}
+	product, err := scanProduct(row)
+	if err != nil {
+		return product, fmt.Errorf("CreateProduct Query Err: %+v", err)
+	}
+	return product, nil
+}
+
+// EditProduct updates only the given columns of a product and returns the updated row
+func (d *Db) EditProduct(ctx context.Context, productID uuid.UUID, values map[string]interface{}) (Product, error) {
+	product, err := scanProduct(updateRow(ctx, d, "product", productID, values))
+	if err != nil {
+		return product, rowErr("EditProduct", "product", productID, err)
+	}
+	return product, nil
+}
+
+// DeleteProduct deletes a product and returns the deleted row
+func (d *Db) DeleteProduct(ctx context.Context, productID uuid.UUID) (Product, error) {
+	product, err := scanProduct(deleteRow(ctx, d, "product", productID))
+	if err != nil {
+		return product, rowErr("DeleteProduct", "product", productID, err)
+	}
+	return product, nil
+}
+
+// CreateStore inserts a store with the given columns and returns it
+func (d *Db) CreateStore(ctx context.Context, values map[string]interface{}) (Store, error) {
+	row, err := insertRow(ctx, d, "store", values)
+	if err != nil {
+		return Store{}, err
+	}
+	store, err := scanStore(row)
+	if err != nil {
+		return store, fmt.Errorf("CreateStore Query Err: %+v", err)
+	}
+	return store, nil
+}
+
+// EditStore updates only the given columns of a store and returns the updated row
+func (d *Db) EditStore(ctx context.Context, storeID uuid.UUID, values map[string]interface{}) (Store, error) {
+	store, err := scanStore(updateRow(ctx, d, "store", storeID, values))
+	if err != nil {
+		return store, rowErr("EditStore", "store", storeID, err)
+	}
+	return store, nil
+}
+
+// DeleteStore deletes a store and returns the deleted row
+func (d *Db) DeleteStore(ctx context.Context, storeID uuid.UUID) (Store, error) {
+	store, err := scanStore(deleteRow(ctx, d, "store", storeID))
+	if err != nil {
+		return store, rowErr("DeleteStore", "store", storeID, err)
+	}
+	return store, nil
 }
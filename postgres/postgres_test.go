@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestSetClause(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   map[string]interface{}
+		wantSet  string
+		wantArgs []interface{}
+	}{
+		{
+			"no columns",
+			map[string]interface{}{},
+			"updated_at = now()",
+			[]interface{}{},
+		},
+		{
+			"sorted columns",
+			map[string]interface{}{"name": "Coffee", "code": "C1"},
+			"code = $1, name = $2, updated_at = now()",
+			[]interface{}{"C1", "Coffee"},
+		},
+		{
+			"merged languages",
+			map[string]interface{}{"names": LanguageJson{"en": "Coffee"}.Merge()},
+			"names = COALESCE(names, '{}'::jsonb) || $1::jsonb, updated_at = now()",
+			[]interface{}{LanguageJson{"en": "Coffee"}},
+		},
+		{
+			"merged and removed languages",
+			map[string]interface{}{
+				"code":  "C1",
+				"names": LanguageJson{"en": "Coffee", "zh": "", "fr": ""}.Merge(),
+				"photo": "coffee.png",
+			},
+			"code = $1, names = (COALESCE(names, '{}'::jsonb) || $2::jsonb) - $3::text[], photo = $4, updated_at = now()",
+			[]interface{}{"C1", LanguageJson{"en": "Coffee"}, pq.Array([]string{"fr", "zh"}), "coffee.png"},
+		},
+		{
+			"removed languages only",
+			map[string]interface{}{"names": LanguageJson{"zh": ""}.Merge()},
+			"names = (COALESCE(names, '{}'::jsonb) || $1::jsonb) - $2::text[], updated_at = now()",
+			[]interface{}{LanguageJson{}, pq.Array([]string{"zh"})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, args := setClause(tt.values)
+			if set != tt.wantSet {
+				t.Errorf("setClause() set = %q, want %q", set, tt.wantSet)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("setClause() args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestColumnValue(t *testing.T) {
+	merge := LanguageJson{"en": "Coffee", "zh": ""}.Merge()
+	if got, want := columnValue(merge), (LanguageJson{"en": "Coffee"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("columnValue(%#v) = %#v, want %#v", merge, got, want)
+	}
+	if got := columnValue("C1"); got != "C1" {
+		t.Errorf("columnValue(%q) = %#v, want %q", "C1", got, "C1")
+	}
+}
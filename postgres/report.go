@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// salesDimensions maps the dimensions of a SalesReport to the expression they
+// are grouped by. Days start at midnight UTC
+var salesDimensions = map[string]string{
+	SalesByStore:         "store_order.store_id",
+	SalesByProduct:       "order_item.product_id",
+	SalesByDay:           "date_trunc('day', store_order.ordered_at AT TIME ZONE 'UTC')",
+	SalesByPaymentMethod: "store_order.payment_method_id",
+}
+
+// salesNulls selects the dimensions a SalesReport is not grouped by
+var salesNulls = map[string]string{
+	SalesByStore:         "NULL::uuid",
+	SalesByProduct:       "NULL::uuid",
+	SalesByDay:           "NULL::timestamp",
+	SalesByPaymentMethod: "NULL::uuid",
+}
+
+// salesAggregates are the SalesTotals of the rows of a SalesReport
+const salesAggregates = `count(DISTINCT store_order.id), COALESCE(sum(order_item.quantity), 0), COALESCE(sum(order_item.quantity * order_item.unit_price), 0)`
+
+// SalesReport aggregates the items of the orders matching filter, grouped by
+// the given dimensions. Without dimensions the report holds a single row
+func (d *Db) SalesReport(ctx context.Context, filter SalesReportFilter, groupBy []string) (SalesReport, error) {
+	report := SalesReport{Rows: []SalesRow{}}
+	if !filter.From.Before(filter.To) {
+		return report, fmt.Errorf("from must be before to")
+	}
+	grouped := make(map[string]bool, len(groupBy))
+	for _, dimension := range groupBy {
+		if _, ok := salesDimensions[dimension]; !ok {
+			return report, fmt.Errorf("cannot group sales by %q", dimension)
+		}
+		grouped[dimension] = true
+	}
+
+	var w where
+	w.add("store_order.ordered_at >= $%d", filter.From)
+	w.add("store_order.ordered_at < $%d", filter.To)
+	if filter.VendorID.Valid {
+		w.add("store.vendor_id = $%d", filter.VendorID.UUID)
+	}
+	from := ` FROM store_order
+		JOIN store ON store.id = store_order.store_id
+		LEFT JOIN order_item ON order_item.order_id = store_order.id` + w.String()
+
+	err := d.QueryRowContext(ctx, `SELECT `+salesAggregates+from, w.args...).
+		Scan(&report.Totals.Orders, &report.Totals.Units, &report.Totals.Revenue)
+	if err != nil {
+		return report, fmt.Errorf("SalesReport Totals Err: %+v", err)
+	}
+
+	// Every dimension is selected so rows always scan the same way
+	var columns, groups []string
+	for _, dimension := range []string{SalesByStore, SalesByProduct, SalesByDay, SalesByPaymentMethod} {
+		if grouped[dimension] {
+			columns = append(columns, salesDimensions[dimension])
+			groups = append(groups, salesDimensions[dimension])
+		} else {
+			columns = append(columns, salesNulls[dimension])
+		}
+	}
+	query := `SELECT ` + strings.Join(columns, ", ") + `, ` + salesAggregates + from
+	if len(groups) > 0 {
+		query += ` GROUP BY ` + strings.Join(groups, ", ") + ` ORDER BY ` + strings.Join(groups, ", ")
+	}
+
+	rows, err := d.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return report, fmt.Errorf("SalesReport Query Err: %+v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var r SalesRow
+		err := rows.Scan(&r.StoreID, &r.ProductID, &r.Day, &r.PaymentMethodID, &r.Orders, &r.Units, &r.Revenue)
+		if err != nil {
+			return report, fmt.Errorf("Error scanning rows: %+v", err)
+		}
+		report.Rows = append(report.Rows, r)
+	}
+	return report, nil
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// searchColumns are the localized product columns searched by
+// SearchProducts, weighted to rank matches in names above brand names and
+// descriptions. Every searched language needs its own trigram index per
+// column, which CreateSearchIndexes creates
+var searchColumns = []struct {
+	column string
+	weight float64
+}{
+	{"names", 1},
+	{"brand_names", 0.8},
+	{"descriptions", 0.4},
+}
+
+// substringLangs are only matched by substring. CJK text has no word
+// boundaries for trigram similarity to work with, while other languages are
+// matched by similarity as well
+var substringLangs = map[string]bool{"zh": true, "ja": true, "ko": true}
+
+// searchLangPattern is the form of the language tags SearchProducts accepts,
+// tags are written into the query so nothing else may pass
+var searchLangPattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+// searchIndexName returns the name of the trigram index of column in lang,
+// e.g. product_names_zh_hk_trgm_idx for zh-HK
+func searchIndexName(column string, lang string) string {
+	return fmt.Sprintf("product_%s_%s_trgm_idx", column, strings.Replace(strings.ToLower(lang), "-", "_", -1))
+}
+
+// CreateSearchIndexes creates the trigram indexes SearchProducts needs to
+// search langs, one per language and searched column. The indexes of the
+// languages that were supported before are left in place
+func (d *Db) CreateSearchIndexes(ctx context.Context, langs []string) error {
+	for _, lang := range langs {
+		if !searchLangPattern.MatchString(lang) {
+			return fmt.Errorf("invalid language tag %q", lang)
+		}
+		for _, c := range searchColumns {
+			query := fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON product USING gin ((%s->>'%s') gin_trgm_ops)`,
+				searchIndexName(c.column, lang), c.column, lang)
+			if _, err := d.ExecContext(ctx, query); err != nil {
+				return fmt.Errorf("CreateSearchIndexes Query Err: %+v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// ProductMatch is a product found by SearchProducts along with the language
+// it matched in and its rank, higher ranks are better matches
+type ProductMatch struct {
+	Product Product `json:"product"`
+	Lang    string  `json:"lang"`
+	Rank    float64 `json:"rank"`
+}
+
+// SearchProducts returns the products whose names, brand names or
+// descriptions match text in any of langs, best matches first
+func (d *Db) SearchProducts(ctx context.Context, text string, langs []string, limit int) ([]ProductMatch, error) {
+	matches := []ProductMatch{}
+	if limit <= 0 || limit > MaxPageSize {
+		return matches, fmt.Errorf("first must be between 1 and %d", MaxPageSize)
+	}
+
+	var conds, fields []string
+	for _, lang := range langs {
+		if !searchLangPattern.MatchString(lang) {
+			return matches, fmt.Errorf("invalid language tag %q", lang)
+		}
+		fuzzy := !substringLangs[strings.ToLower(strings.SplitN(lang, "-", 2)[0])]
+		for _, c := range searchColumns {
+			expr := fmt.Sprintf("(p.%s->>'%s')", c.column, lang)
+			conds = append(conds, expr+" ILIKE $2")
+			if fuzzy {
+				conds = append(conds, expr+" % $1")
+			}
+			fields = append(fields, fmt.Sprintf("('%s', %s, %g::float8, %t)", lang, expr, c.weight, fuzzy))
+		}
+	}
+	if len(fields) == 0 {
+		return matches, fmt.Errorf("no language to search")
+	}
+
+	// The outer conditions select candidates through the trigram indexes, the
+	// lateral join then ranks each of them by its best matching field
+	query := fmt.Sprintf(`SELECT p.*, m.lang, m.rank FROM product p
+		CROSS JOIN LATERAL (
+			SELECT f.lang, max(f.weight * CASE
+				WHEN lower(f.text) = lower($1) THEN 1.0
+				WHEN f.text ILIKE $2 THEN 0.5 + 0.5 * similarity(f.text, $1)
+				ELSE similarity(f.text, $1)
+			END) AS rank
+			FROM (VALUES %s) AS f(lang, text, weight, fuzzy)
+			WHERE f.text ILIKE $2 OR (f.fuzzy AND f.text %% $1)
+			GROUP BY f.lang
+			ORDER BY rank DESC
+			LIMIT 1
+		) m
+		WHERE %s
+		ORDER BY m.rank DESC, p.id
+		LIMIT $3`, strings.Join(fields, ", "), strings.Join(conds, " OR "))
+
+	rows, err := d.QueryContext(ctx, query, text, containsPattern(text), limit)
+	if err != nil {
+		return matches, fmt.Errorf("SearchProducts Query Err: %+v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var m ProductMatch
+		m.Product, err = scanProduct(rows, &m.Lang, &m.Rank)
+		if err != nil {
+			return matches, fmt.Errorf("Error scanning rows: %+v", err)
+		}
+		matches = append(matches, m)
+	}
+	return matches, nil
+}
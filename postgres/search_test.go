@@ -0,0 +1,22 @@
+package postgres
+
+import "testing"
+
+func TestSearchIndexName(t *testing.T) {
+	tests := []struct {
+		column string
+		lang   string
+		want   string
+	}{
+		// The indexes of migrations/001_product_search.sql
+		{"names", "en", "product_names_en_trgm_idx"},
+		{"brand_names", "zh", "product_brand_names_zh_trgm_idx"},
+		{"descriptions", "zh-HK", "product_descriptions_zh_hk_trgm_idx"},
+		{"names", "zh-Hant-HK", "product_names_zh_hant_hk_trgm_idx"},
+	}
+	for _, tt := range tests {
+		if got := searchIndexName(tt.column, tt.lang); got != tt.want {
+			t.Errorf("searchIndexName(%q, %q) = %q, want %q", tt.column, tt.lang, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+)
+
+// scanSupplier copies the columns of a supplier row into a Supplier
+func scanSupplier(row scanner) (Supplier, error) {
+	var r Supplier
+	err := row.Scan(
+		&r.ID,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+		&r.MongoID,
+		&r.Code,
+		&r.Name,
+		&r.ContactName,
+		&r.Phone,
+		&r.Email,
+		&r.Address,
+	)
+	return r, err
+}
+
+// GetSuppliers returns the suppliers with the given ids
+func (d *Db) GetSuppliers(ctx context.Context, supplierIDs []uuid.UUID) ([]Supplier, error) {
+	// Create slice of Suppliers for our response
+	suppliers := []Supplier{}
+	rows, err := d.QueryContext(ctx, `SELECT * FROM supplier WHERE id = ANY($1)`, pq.Array(supplierIDs))
+
+	if err != nil {
+		return suppliers, fmt.Errorf("GetSuppliers Query Err: %+v", err)
+	}
+
+	defer rows.Close()
+
+	// Copy the columns from each row into a Supplier
+	for rows.Next() {
+		r, err := scanSupplier(rows)
+		if err != nil {
+			return suppliers, fmt.Errorf("Error scanning rows: %+v", err)
+		}
+		suppliers = append(suppliers, r)
+	}
+	return suppliers, nil
+}
+
+// ListSuppliers returns a page of the suppliers matching filter
+func (d *Db) ListSuppliers(ctx context.Context, filter SupplierFilter, page Page) (SupplierPage, error) {
+	result := SupplierPage{Suppliers: []Supplier{}}
+	var w where
+	if filter.NameContains != "" {
+		w.add("name ILIKE $%d", containsPattern(filter.NameContains))
+	}
+	if filter.Code.Valid {
+		w.add("code = $%d", filter.Code.String)
+	}
+
+	total, limit, err := d.listPage(ctx, "ListSuppliers", "supplier", w, page, []string{"created_at", "name"}, func(row scanner) error {
+		r, err := scanSupplier(row)
+		result.Suppliers = append(result.Suppliers, r)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+	result.TotalCount = total
+	if len(result.Suppliers) > limit {
+		result.Suppliers = result.Suppliers[:limit]
+		result.HasNextPage = true
+	}
+	return result, nil
+}
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Telemetry events a store can report
+const (
+	TelemetryHeartbeat = "heartbeat"
+	TelemetrySync      = "sync"
+)
+
+// StoreTelemetry is a report sent by a store about its state. Unset values
+// leave the matching store columns untouched
+type StoreTelemetry struct {
+	StoreID               uuid.UUID
+	Event                 string
+	Online                bool
+	SyncedAt              pq.NullTime
+	LastGet               pq.NullTime
+	LastRefill            pq.NullTime
+	LastReset             pq.NullTime
+	UnsubmittedOrderCount sql.NullInt64
+}
+
+// Validate returns an error describing the first invalid value of the report
+func (t StoreTelemetry) Validate() error {
+	if t.Event != TelemetryHeartbeat && t.Event != TelemetrySync {
+		return fmt.Errorf("unknown telemetry event %q", t.Event)
+	}
+	if t.UnsubmittedOrderCount.Valid && t.UnsubmittedOrderCount.Int64 < 0 {
+		return fmt.Errorf("unsubmitted_order_count must not be negative")
+	}
+	return nil
+}
+
+// RecordStoreTelemetry applies a telemetry report to its store and appends it
+// to the store_telemetry history in one transaction, returning the updated store
+func (d *Db) RecordStoreTelemetry(ctx context.Context, t StoreTelemetry) (Store, error) {
+	if err := t.Validate(); err != nil {
+		return Store{}, err
+	}
+	id, err := uuid.NewV4()
+	if err != nil {
+		return Store{}, err
+	}
+
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return Store{}, fmt.Errorf("RecordStoreTelemetry Begin Err: %+v", err)
+	}
+
+	store, err := scanStore(tx.QueryRowContext(ctx, `UPDATE store SET
+			last_online_at = CASE WHEN $2 THEN now() ELSE last_online_at END,
+			last_sync = COALESCE($3, last_sync),
+			last_get = COALESCE($4, last_get),
+			last_refill = COALESCE($5, last_refill),
+			last_reset = COALESCE($6, last_reset),
+			unsubmitted_order_count = COALESCE($7, unsubmitted_order_count),
+			updated_at = now()
+		WHERE id = $1 RETURNING *`,
+		t.StoreID, t.Online, t.SyncedAt, t.LastGet, t.LastRefill, t.LastReset, t.UnsubmittedOrderCount))
+	if err != nil {
+		tx.Rollback()
+		return store, rowErr("RecordStoreTelemetry", "store", t.StoreID, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO store_telemetry
+			(id, store_id, event, online, synced_at, last_get, last_refill, last_reset, unsubmitted_order_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		id, t.StoreID, t.Event, t.Online, t.SyncedAt, t.LastGet, t.LastRefill, t.LastReset, t.UnsubmittedOrderCount)
+	if err != nil {
+		tx.Rollback()
+		return store, fmt.Errorf("RecordStoreTelemetry Insert Err: %+v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return store, fmt.Errorf("RecordStoreTelemetry Commit Err: %+v", err)
+	}
+	return store, nil
+}
+
+// ListStaleStores returns the stores that have not synced or have not been
+// refilled since before, least recently synced first. An invalid vendorID
+// lists the stores of every vendor
+func (d *Db) ListStaleStores(ctx context.Context, vendorID uuid.NullUUID, before time.Time) ([]Store, error) {
+	stores := []Store{}
+	var w where
+	if vendorID.Valid {
+		w.add("vendor_id = $%d", vendorID.UUID)
+	}
+	w.add("(last_sync IS NULL OR last_sync < $%d OR last_refill IS NULL OR last_refill < $%d)", before, before)
+
+	rows, err := d.QueryContext(ctx, `SELECT * FROM store`+w.String()+` ORDER BY last_sync ASC NULLS FIRST, id`, w.args...)
+	if err != nil {
+		return stores, fmt.Errorf("ListStaleStores Query Err: %+v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		r, err := scanStore(rows)
+		if err != nil {
+			return stores, fmt.Errorf("Error scanning rows: %+v", err)
+		}
+		stores = append(stores, r)
+	}
+	return stores, nil
+}
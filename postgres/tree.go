@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Upsert describes a row to write, it is updated when ID is valid and
+// inserted otherwise
+type Upsert struct {
+	ID     uuid.NullUUID
+	Values map[string]interface{}
+}
+
+// UpsertVendorTree writes a vendor together with its products and stores in a
+// single transaction. Products and stores that are updated must already belong
+// to the vendor. Nothing is written if any row fails
+func (d *Db) UpsertVendorTree(ctx context.Context, vendor Upsert, products []Upsert, stores []Upsert) (Vendor, error) {
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return Vendor{}, fmt.Errorf("UpsertVendorTree Begin Err: %+v", err)
+	}
+
+	v, err := upsertVendorTree(ctx, tx, vendor, products, stores)
+	if err != nil {
+		tx.Rollback()
+		return Vendor{}, err
+	}
+	if err = tx.Commit(); err != nil {
+		return Vendor{}, fmt.Errorf("UpsertVendorTree Commit Err: %+v", err)
+	}
+	return v, nil
+}
+
+func upsertVendorTree(ctx context.Context, tx *sql.Tx, vendor Upsert, products []Upsert, stores []Upsert) (Vendor, error) {
+	var (
+		v   Vendor
+		err error
+	)
+	if vendor.ID.Valid {
+		v, err = scanVendor(updateRow(ctx, tx, "vendor", vendor.ID.UUID, vendor.Values))
+		if err != nil {
+			return v, rowErr("UpsertVendorTree", "vendor", vendor.ID.UUID, err)
+		}
+	} else {
+		row, err := insertRow(ctx, tx, "vendor", vendor.Values)
+		if err != nil {
+			return v, err
+		}
+		if v, err = scanVendor(row); err != nil {
+			return v, fmt.Errorf("UpsertVendorTree Query Err: %+v", err)
+		}
+	}
+
+	for i, product := range products {
+		row, err := upsertVendorChild(ctx, tx, "product", v.ID, product)
+		if err != nil {
+			return v, err
+		}
+		if _, err = scanProduct(row); err != nil {
+			return v, childErr("product", i, product.ID, err)
+		}
+	}
+	for i, store := range stores {
+		row, err := upsertVendorChild(ctx, tx, "store", v.ID, store)
+		if err != nil {
+			return v, err
+		}
+		if _, err = scanStore(row); err != nil {
+			return v, childErr("store", i, store.ID, err)
+		}
+	}
+	return v, nil
+}
+
+// upsertVendorChild writes a row of table owned by the vendor. An update only
+// matches rows already belonging to the vendor
+func upsertVendorChild(ctx context.Context, tx *sql.Tx, table string, vendorID uuid.UUID, child Upsert) (*sql.Row, error) {
+	values := make(map[string]interface{}, len(child.Values)+1)
+	for column, value := range child.Values {
+		values[column] = value
+	}
+	values["vendor_id"] = vendorID
+
+	if !child.ID.Valid {
+		return insertRow(ctx, tx, table, values)
+	}
+	set, args := setClause(values)
+	query := fmt.Sprintf(`UPDATE %s SET %s WHERE id = $%d AND vendor_id = $%d RETURNING *`, table, set, len(args)+1, len(args)+2)
+	return tx.QueryRowContext(ctx, query, append(args, child.ID.UUID, vendorID)...), nil
+}
+
+// childErr describes the failure of the i-th child row of a vendor tree
+func childErr(table string, i int, id uuid.NullUUID, err error) error {
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%ss[%d]: %s %s not found for this vendor", table, i, table, id.UUID)
+	}
+	return fmt.Errorf("%ss[%d]: UpsertVendorTree Query Err: %+v", table, i, err)
+}
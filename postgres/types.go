@@ -5,6 +5,8 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
@@ -14,25 +16,57 @@ import (
 
 // Payment Method shape
 type PaymentMethod struct {
-	ID            uuid.UUID `db:"id" json:"id"`
-	CreatedAt     time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
-	MongoID       string    `db:"mongo_id" json:"mongo_id"`
-	Code          string    `db:"code" json:"code"`
-	Name          string    `db:"name" json:"name"`
-	Module        string    `db:"module" json:"module"`
-	ModuleChannel string    `db:"module_channel" json:"module_channel"`
-	OrderIndex    string    `db:"order_index" json:"order_index"`
+	ID            uuid.UUID      `db:"id" json:"id"`
+	CreatedAt     time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time      `db:"updated_at" json:"updated_at"`
+	MongoID       sql.NullString `db:"mongo_id" json:"mongo_id"`
+	Code          string         `db:"code" json:"code"`
+	Name          string         `db:"name" json:"name"`
+	Module        sql.NullString `db:"module" json:"module"`
+	ModuleChannel sql.NullString `db:"module_channel" json:"module_channel"`
+	OrderIndex    int64          `db:"order_index" json:"order_index"`
+}
+
+// StorePaymentMethod is a payment method accepted by a store
+type StorePaymentMethod struct {
+	StoreID uuid.UUID
+	PaymentMethod
 }
 
 type Vendor struct {
-	ID          uuid.UUID `db:"id" json:"id,omitempty"`
-	CreatedAt   time.Time `db:"created_at" json:"created_at,omitempty"`
-	UpdatedAt   time.Time `db:"updated_at" json:"updated_at,omitempty"`
-	MongoID     string    `db:"mongo_id" json:"mongo_id,omitempty"`
-	Name        string    `db:"name" json:"name,omitempty"`
-	Description string    `db:"description" json:"description,omitempty"`
-	Products    []Product `json:"products,omitempty"`
+	ID          uuid.UUID      `db:"id" json:"id,omitempty"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at,omitempty"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at,omitempty"`
+	MongoID     sql.NullString `db:"mongo_id" json:"mongo_id,omitempty"`
+	Name        string         `db:"name" json:"name,omitempty"`
+	Description sql.NullString `db:"description" json:"description,omitempty"`
+	Products    []Product      `json:"products,omitempty"`
+}
+
+// Cursor returns a cursor pointing at the vendor in a page ordered by orderBy
+func (v Vendor) Cursor(orderBy string) Cursor {
+	c := Cursor{OrderBy: orderBy, ID: v.ID}
+	switch orderBy {
+	case "name":
+		c.Value = v.Name
+	default:
+		c.Value = v.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return c
+}
+
+// VendorFilter narrows down the vendors returned by ListVendors
+type VendorFilter struct {
+	NameContains  string
+	CreatedAfter  pq.NullTime
+	CreatedBefore pq.NullTime
+}
+
+// VendorPage is a page of vendors along with the total number of matches
+type VendorPage struct {
+	Vendors     []Vendor
+	HasNextPage bool
+	TotalCount  int
 }
 
 // Product shape
@@ -48,11 +82,77 @@ type Product struct {
 	Descriptions     LanguageJson   `db:"descriptions" json:"descriptions,omitempty"`
 	BrandNames       LanguageJson   `db:"brand_names" json:"brand_names,omitempty"`
 	Names            LanguageJson   `db:"names" json:"names,omitempty"`
-	OptionalData     LanguageJson   `db:"optional_data" json:"optional_data,omitempty"`
+	OptionalData     JSONMap        `db:"optional_data" json:"optional_data,omitempty"`
 	VendorID         uuid.NullUUID  `db:"vendor_id" json:"vendor_id,omitempty"`
 	SupplierID       uuid.NullUUID  `db:"supplier_id" json:"supplier_id,omitempty"`
 }
 
+// Cursor returns a cursor pointing at the product in a page ordered by orderBy
+func (p Product) Cursor(orderBy string) Cursor {
+	c := Cursor{OrderBy: orderBy, ID: p.ID}
+	switch orderBy {
+	case "updated_at":
+		c.Value = p.UpdatedAt.Format(time.RFC3339Nano)
+	default:
+		c.Value = p.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return c
+}
+
+// ProductFilter narrows down the products returned by ListProducts
+type ProductFilter struct {
+	VendorID         uuid.NullUUID
+	SupplierID       uuid.NullUUID
+	IsVirtualProduct sql.NullBool
+	Code             sql.NullString
+}
+
+// ProductPage is a page of products along with the total number of matches
+type ProductPage struct {
+	Products    []Product
+	HasNextPage bool
+	TotalCount  int
+}
+
+// Supplier shape
+type Supplier struct {
+	ID          uuid.UUID      `db:"id" json:"id,omitempty"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at,omitempty"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at,omitempty"`
+	MongoID     sql.NullString `db:"mongo_id" json:"mongo_id,omitempty"`
+	Code        sql.NullString `db:"code" json:"code,omitempty"`
+	Name        string         `db:"name" json:"name,omitempty"`
+	ContactName sql.NullString `db:"contact_name" json:"contact_name,omitempty"`
+	Phone       sql.NullString `db:"phone" json:"phone,omitempty"`
+	Email       sql.NullString `db:"email" json:"email,omitempty"`
+	Address     sql.NullString `db:"address" json:"address,omitempty"`
+}
+
+// Cursor returns a cursor pointing at the supplier in a page ordered by orderBy
+func (s Supplier) Cursor(orderBy string) Cursor {
+	c := Cursor{OrderBy: orderBy, ID: s.ID}
+	switch orderBy {
+	case "name":
+		c.Value = s.Name
+	default:
+		c.Value = s.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return c
+}
+
+// SupplierFilter narrows down the suppliers returned by ListSuppliers
+type SupplierFilter struct {
+	NameContains string
+	Code         sql.NullString
+}
+
+// SupplierPage is a page of suppliers along with the total number of matches
+type SupplierPage struct {
+	Suppliers   []Supplier
+	HasNextPage bool
+	TotalCount  int
+}
+
 // Store shape
 type Store struct {
 	ID                    uuid.UUID      `db:"id" json:"id,omitempty"`
@@ -72,30 +172,289 @@ type Store struct {
 	VendorID              uuid.NullUUID  `db:"vendor_id" json:"vendor_id,omitempty"`
 }
 
-type LanguageJson struct {
-	En string `db:"en" json:"en,omitempty"`
-	Zh string `db:"zh" json:"zh,omitempty"`
+// Store statuses derived from last_online_at
+const (
+	StoreOnline  = "online"
+	StoreStale   = "stale"
+	StoreOffline = "offline"
+)
+
+// Status returns StoreOnline when the store was last online within staleAfter
+// of now, StoreStale when within offlineAfter and StoreOffline otherwise
+func (s Store) Status(now time.Time, staleAfter time.Duration, offlineAfter time.Duration) string {
+	if !s.LastOnlineAt.Valid {
+		return StoreOffline
+	}
+	switch since := now.Sub(s.LastOnlineAt.Time); {
+	case since <= staleAfter:
+		return StoreOnline
+	case since <= offlineAfter:
+		return StoreStale
+	}
+	return StoreOffline
+}
+
+// Cursor returns a cursor pointing at the store in a page ordered by orderBy
+func (s Store) Cursor(orderBy string) Cursor {
+	c := Cursor{OrderBy: orderBy, ID: s.ID}
+	switch orderBy {
+	case "updated_at":
+		c.Value = s.UpdatedAt.Format(time.RFC3339Nano)
+	default:
+		c.Value = s.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return c
+}
+
+// StoreFilter narrows down the stores returned by ListStores
+type StoreFilter struct {
+	VendorID uuid.NullUUID
+	Code     sql.NullString
+}
+
+// StorePage is a page of stores along with the total number of matches
+type StorePage struct {
+	Stores      []Store
+	HasNextPage bool
+	TotalCount  int
+}
+
+// InventorySlot is a slot of a store stocked with a product, prices are in
+// the smallest unit of the currency
+type InventorySlot struct {
+	ID        uuid.UUID     `db:"id" json:"id,omitempty"`
+	CreatedAt time.Time     `db:"created_at" json:"created_at,omitempty"`
+	UpdatedAt time.Time     `db:"updated_at" json:"updated_at,omitempty"`
+	StoreID   uuid.UUID     `db:"store_id" json:"store_id,omitempty"`
+	ProductID uuid.NullUUID `db:"product_id" json:"product_id,omitempty"`
+	Slot      int64         `db:"slot" json:"slot"`
+	Capacity  int64         `db:"capacity" json:"capacity"`
+	Quantity  int64         `db:"quantity" json:"quantity"`
+	Price     int64         `db:"price" json:"price"`
+}
+
+// SlotRefill refills a slot of a store, creating it when it does not exist
+// yet. Unset values keep those of the slot, an unset Quantity fills the slot
+// up to its capacity
+type SlotRefill struct {
+	Slot      int64
+	Quantity  sql.NullInt64
+	ProductID uuid.NullUUID
+	Capacity  sql.NullInt64
+	Price     sql.NullInt64
+}
+
+// Order is a sale made by a store, identified by the store through its
+// ClientOrderID. Amounts are in the smallest unit of the currency
+type Order struct {
+	ID              uuid.UUID     `db:"id" json:"id,omitempty"`
+	CreatedAt       time.Time     `db:"created_at" json:"created_at,omitempty"`
+	UpdatedAt       time.Time     `db:"updated_at" json:"updated_at,omitempty"`
+	StoreID         uuid.UUID     `db:"store_id" json:"store_id,omitempty"`
+	ClientOrderID   string        `db:"client_order_id" json:"client_order_id,omitempty"`
+	PaymentMethodID uuid.NullUUID `db:"payment_method_id" json:"payment_method_id,omitempty"`
+	OrderedAt       time.Time     `db:"ordered_at" json:"ordered_at,omitempty"`
+	Total           int64         `db:"total" json:"total"`
+}
+
+// OrderItem is a product sold as part of an Order
+type OrderItem struct {
+	ID        uuid.UUID     `db:"id" json:"id,omitempty"`
+	CreatedAt time.Time     `db:"created_at" json:"created_at,omitempty"`
+	OrderID   uuid.UUID     `db:"order_id" json:"order_id,omitempty"`
+	ProductID uuid.NullUUID `db:"product_id" json:"product_id,omitempty"`
+	Slot      sql.NullInt64 `db:"slot" json:"slot,omitempty"`
+	Quantity  int64         `db:"quantity" json:"quantity"`
+	UnitPrice int64         `db:"unit_price" json:"unit_price"`
+}
+
+// OrderSubmission is an order buffered by a store until it could be submitted.
+// An unset OrderedAt is the time of the submission
+type OrderSubmission struct {
+	ClientOrderID   string
+	PaymentMethodID uuid.NullUUID
+	OrderedAt       pq.NullTime
+	Items           []OrderItemSubmission
+}
+
+// OrderItemSubmission is an item of an OrderSubmission. The item is taken from
+// Slot, or else from the fullest slot stocked with ProductID, which also fill
+// in the product and unit price when they are not set. When both are set,
+// Slot must be stocked with ProductID
+type OrderItemSubmission struct {
+	ProductID uuid.NullUUID
+	Slot      sql.NullInt64
+	Quantity  int64
+	UnitPrice sql.NullInt64
+}
+
+// Dimensions a SalesReport can be grouped by
+const (
+	SalesByStore         = "store"
+	SalesByProduct       = "product"
+	SalesByDay           = "day"
+	SalesByPaymentMethod = "payment_method"
+)
+
+// SalesReportFilter narrows down the orders a SalesReport aggregates to those
+// ordered from From until before To
+type SalesReportFilter struct {
+	VendorID uuid.NullUUID
+	From     time.Time
+	To       time.Time
+}
+
+// SalesTotals aggregates orders, amounts are in the smallest unit of the currency
+type SalesTotals struct {
+	Orders  int64 `json:"orders"`
+	Units   int64 `json:"units"`
+	Revenue int64 `json:"revenue"`
+}
+
+// SalesRow aggregates the orders sharing the values of the dimensions a
+// SalesReport is grouped by, the other dimensions are unset
+type SalesRow struct {
+	StoreID         uuid.NullUUID `json:"store_id"`
+	ProductID       uuid.NullUUID `json:"product_id"`
+	Day             pq.NullTime   `json:"day"`
+	PaymentMethodID uuid.NullUUID `json:"payment_method_id"`
+	Orders          int64         `json:"orders"`
+	Units           int64         `json:"units"`
+	Revenue         int64         `json:"revenue"`
+}
+
+// SalesReport is the grouped rows of a sales report along with their totals
+type SalesReport struct {
+	Rows   []SalesRow  `json:"rows"`
+	Totals SalesTotals `json:"totals"`
+}
+
+// LanguageJson maps BCP 47 language tags, e.g. en or zh-HK, to the text in
+// that language
+type LanguageJson map[string]string
+
+// Get returns the text stored for lang, tags are matched case insensitively
+func (lj LanguageJson) Get(lang string) (string, bool) {
+	if text, ok := lj[lang]; ok && text != "" {
+		return text, true
+	}
+	for tag, text := range lj {
+		if strings.EqualFold(tag, lang) && text != "" {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+// Langs returns the sorted tags of the languages that have a text
+func (lj LanguageJson) Langs() []string {
+	langs := make([]string, 0, len(lj))
+	for tag, text := range lj {
+		if text != "" {
+			langs = append(langs, tag)
+		}
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Merge returns lj as the value of a column whose languages are merged into
+// the languages a row already has, the languages with an empty text are
+// removed from the row instead
+func (lj LanguageJson) Merge() JSONMerge {
+	texts := LanguageJson{}
+	var remove []string
+	for tag, text := range lj {
+		if text == "" {
+			remove = append(remove, tag)
+			continue
+		}
+		texts[tag] = text
+	}
+	sort.Strings(remove)
+	return JSONMerge{Value: texts, Remove: remove}
 }
 
 // Make the Attrs struct implement the driver.Valuer interface. This method
-// simply returns the JSON-encoded representation of the struct.
+// simply returns the JSON-encoded representation of the map.
 func (lj LanguageJson) Value() (driver.Value, error) {
-	return json.Marshal(lj)
+	if lj == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string]string(lj))
 }
 
 // Make the Attrs struct implement the sql.Scanner interface. This method
-// simply decodes a JSON-encoded value into the struct fields.
+// simply decodes a JSON-encoded value into the map, keeping every language.
 func (lj *LanguageJson) Scan(value interface{}) error {
 	newLJ := LanguageJson{}
-	b, ok := value.([]byte)
-	if !ok {
+	var b []byte
+	switch value := value.(type) {
+	case nil:
+		*lj = newLJ
+		return nil
+	case []byte:
+		b = value
+	case string:
+		b = []byte(value)
+	default:
 		return errors.New("type assertion to []byte failed")
 	}
 	err := json.Unmarshal(b, &newLJ)
 	if err != nil {
 		return err
 	}
-	// need to assign since if the field does not exist, it will inherit into the next scan with the same type
+	// need to assign since a map decoded into would keep the languages of the previous scan
 	*lj = newLJ
 	return nil
 }
+
+// JSONMap is a jsonb object holding values of any JSON type
+type JSONMap map[string]interface{}
+
+// Merge returns m as the value of a column whose keys are merged into the
+// keys a row already has, the keys set to null are removed from the row
+// instead
+func (m JSONMap) Merge() JSONMerge {
+	values := JSONMap{}
+	var remove []string
+	for key, value := range m {
+		if value == nil {
+			remove = append(remove, key)
+			continue
+		}
+		values[key] = value
+	}
+	sort.Strings(remove)
+	return JSONMerge{Value: values, Remove: remove}
+}
+
+// Value returns the JSON-encoded representation of the map
+func (m JSONMap) Value() (driver.Value, error) {
+	if m == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string]interface{}(m))
+}
+
+// Scan decodes a JSON-encoded object into the map
+func (m *JSONMap) Scan(value interface{}) error {
+	newM := JSONMap{}
+	var b []byte
+	switch value := value.(type) {
+	case nil:
+		*m = newM
+		return nil
+	case []byte:
+		b = value
+	case string:
+		b = []byte(value)
+	default:
+		return errors.New("type assertion to []byte failed")
+	}
+	if err := json.Unmarshal(b, &newM); err != nil {
+		return err
+	}
+	*m = newM
+	return nil
+}
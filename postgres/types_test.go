@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestJSONMapRoundTrip(t *testing.T) {
+	m := JSONMap{
+		"weight":  float64(250),
+		"rating":  4.5,
+		"organic": true,
+		"label":   "fair trade",
+		"tags":    []interface{}{"fair", float64(7), false},
+		"origin":  map[string]interface{}{"country": "KE", "altitude": nil},
+	}
+	value, err := m.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got JSONMap
+	if err := got.Scan(value); err != nil {
+		t.Fatalf("Scan(%s) error = %v", value, err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("Scan(Value()) = %#v, want %#v", got, m)
+	}
+
+	// A second scan replaces the keys of the first
+	if err := got.Scan(`{"weight": 1}`); err != nil {
+		t.Fatal(err)
+	}
+	if want := (JSONMap{"weight": float64(1)}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Scan() = %#v, want %#v", got, want)
+	}
+	if err := got.Scan(nil); err != nil || len(got) != 0 {
+		t.Errorf("Scan(nil) = %#v, %v, want an empty map", got, err)
+	}
+}
+
+func TestJSONMapMerge(t *testing.T) {
+	m := JSONMap{"weight": 250, "organic": false, "label": nil, "origin": nil}
+	want := JSONMerge{Value: JSONMap{"weight": 250, "organic": false}, Remove: []string{"label", "origin"}}
+	if got := m.Merge(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() = %#v, want %#v", got, want)
+	}
+}
+
+func TestStoreStatus(t *testing.T) {
+	now := time.Date(2019, 3, 14, 15, 0, 0, 0, time.UTC)
+	onlineAt := func(ago time.Duration) Store {
+		return Store{LastOnlineAt: pq.NullTime{Time: now.Add(-ago), Valid: true}}
+	}
+	tests := []struct {
+		name  string
+		store Store
+		want  string
+	}{
+		{"never online", Store{}, StoreOffline},
+		{"just online", onlineAt(0), StoreOnline},
+		{"online in the future", onlineAt(-time.Minute), StoreOnline},
+		{"at the stale threshold", onlineAt(5 * time.Minute), StoreOnline},
+		{"past the stale threshold", onlineAt(5*time.Minute + time.Second), StoreStale},
+		{"at the offline threshold", onlineAt(30 * time.Minute), StoreStale},
+		{"past the offline threshold", onlineAt(30*time.Minute + time.Second), StoreOffline},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.store.Status(now, 5*time.Minute, 30*time.Minute); got != tt.want {
+				t.Errorf("Status() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package scalar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/lib/pq"
+)
+
+// DateTimeLayout is the one format of the DateTime and SpecialDate scalars,
+// the offset is required so every time is unambiguous
+const DateTimeLayout = time.RFC3339
+
+// DateLayout is the format of the Date scalar
+const DateLayout = "2006-01-02"
+
+// Date is a calendar day without a time of day or a timezone. The Time it
+// holds is midnight UTC of the day
+type Date struct {
+	time.Time
+}
+
+// NewDate returns the day of t in the location of t
+func NewDate(t time.Time) Date {
+	return Date{time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate parses a day in the DateLayout format
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return Date{}, err
+	}
+	return Date{t}, nil
+}
+
+// String returns the day in the DateLayout format
+func (d Date) String() string {
+	return d.Format(DateLayout)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+func (d *Date) UnmarshalJSON(input []byte) error {
+	date, err := ParseDate(strings.Trim(string(input), `"`))
+	if err != nil {
+		return err
+	}
+	*d = date
+	return nil
+}
+
+var DateTimeScalar = newScalar(
+	"DateTime",
+	"The `DateTime` scalar type represents an instant as an RFC3339 string with its timezone offset, e.g. `2019-03-14T15:09:26+01:00`.",
+	serializeDateTime,
+	parseDateTime,
+	parseDateTimeLiteral,
+)
+
+// serializeDateTime serializes a time, keeping its offset, to the DateTimeLayout format
+func serializeDateTime(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		return value.Format(DateTimeLayout), nil
+	case SpecialDate:
+		return value.Format(DateTimeLayout), nil
+	case pq.NullTime:
+		if !value.Valid {
+			return nil, nil
+		}
+		return value.Time.Format(DateTimeLayout), nil
+	case string:
+		t, err := parseDateTime(value)
+		if err != nil {
+			return nil, err
+		}
+		return t.(time.Time).Format(DateTimeLayout), nil
+	}
+	if v, ok := deref(value); ok {
+		return serializeDateTime(v)
+	}
+	return nil, unsupported("DateTime", value)
+}
+
+// parseDateTime parses a variable in the DateTimeLayout format to a time
+func parseDateTime(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case time.Time:
+		return value, nil
+	case SpecialDate:
+		return value.Time, nil
+	case string:
+		t, err := time.Parse(DateTimeLayout, value)
+		if err != nil {
+			return nil, fmt.Errorf("DateTime cannot represent %q: %v", value, err)
+		}
+		return t, nil
+	}
+	return nil, unsupported("DateTime", value)
+}
+
+// parseDateTimeLiteral parses a string literal in the DateTimeLayout format to a time
+func parseDateTimeLiteral(valueAST ast.Value) (interface{}, error) {
+	if valueAST, ok := valueAST.(*ast.StringValue); ok {
+		return parseDateTime(valueAST.Value)
+	}
+	return nil, unsupportedLiteral("DateTime", valueAST)
+}
+
+var DateScalar = newScalar(
+	"Date",
+	"The `Date` scalar type represents a calendar day without a timezone, e.g. `2019-03-14`.",
+	serializeDate,
+	parseDateValue,
+	parseDateLiteral,
+)
+
+// serializeDate serializes a Date, or the day of a time in its location, to
+// the DateLayout format
+func serializeDate(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case Date:
+		return value.String(), nil
+	case time.Time:
+		return NewDate(value).String(), nil
+	case pq.NullTime:
+		if !value.Valid {
+			return nil, nil
+		}
+		return NewDate(value.Time).String(), nil
+	case string:
+		date, err := parseDateValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return date.(Date).String(), nil
+	}
+	if v, ok := deref(value); ok {
+		return serializeDate(v)
+	}
+	return nil, unsupported("Date", value)
+}
+
+// parseDateValue parses a variable in the DateLayout format to a Date
+func parseDateValue(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case Date:
+		return value, nil
+	case string:
+		date, err := ParseDate(value)
+		if err != nil {
+			return nil, fmt.Errorf("Date cannot represent %q: %v", value, err)
+		}
+		return date, nil
+	}
+	return nil, unsupported("Date", value)
+}
+
+// parseDateLiteral parses a string literal in the DateLayout format to a Date
+func parseDateLiteral(valueAST ast.Value) (interface{}, error) {
+	if valueAST, ok := valueAST.(*ast.StringValue); ok {
+		return parseDateValue(valueAST.Value)
+	}
+	return nil, unsupportedLiteral("Date", valueAST)
+}
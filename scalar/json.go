@@ -0,0 +1,105 @@
+package scalar
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// jsonObjectType is the type JSONObject values are parsed to
+var jsonObjectType = reflect.TypeOf(map[string]interface{}{})
+
+var JSONObjectScalar = newScalar(
+	"JSONObject",
+	"The `JSONObject` scalar type represents a JSON object whose values may be of any JSON type, e.g. `{\"weight\": 250, \"organic\": true, \"origin\": {\"country\": \"KE\"}}`.",
+	serializeJSONObject,
+	parseJSONObject,
+	parseJSONObjectLiteral,
+)
+
+// serializeJSONObject serializes a map with string keys, such as a
+// postgres.JSONMap, to a JSON object
+func serializeJSONObject(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return value, nil
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Map && rv.Type().ConvertibleTo(jsonObjectType) {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return rv.Convert(jsonObjectType).Interface(), nil
+	}
+	if v, ok := deref(value); ok {
+		return serializeJSONObject(v)
+	}
+	return nil, unsupported("JSONObject", value)
+}
+
+// parseJSONObject parses a variable holding a JSON object to a map
+func parseJSONObject(value interface{}) (interface{}, error) {
+	if value, ok := value.(map[string]interface{}); ok {
+		return value, nil
+	}
+	return nil, unsupported("JSONObject", value)
+}
+
+// parseJSONObjectLiteral parses an object literal to a map of the JSON values
+// of its fields
+func parseJSONObjectLiteral(valueAST ast.Value) (interface{}, error) {
+	if _, ok := valueAST.(*ast.ObjectValue); !ok {
+		return nil, unsupportedLiteral("JSONObject", valueAST)
+	}
+	return jsonLiteral(valueAST)
+}
+
+// jsonLiteral returns the JSON value of a literal, enum values are strings.
+// Variables cannot be used inside a JSONObject literal
+func jsonLiteral(valueAST ast.Value) (interface{}, error) {
+	switch valueAST := valueAST.(type) {
+	case *ast.StringValue:
+		return valueAST.Value, nil
+	case *ast.EnumValue:
+		return valueAST.Value, nil
+	case *ast.BooleanValue:
+		return valueAST.Value, nil
+	case *ast.IntValue:
+		i, err := strconv.ParseInt(valueAST.Value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("JSONObject cannot represent %s: %v", valueAST.Value, err)
+		}
+		return i, nil
+	case *ast.FloatValue:
+		f, err := strconv.ParseFloat(valueAST.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("JSONObject cannot represent %s: %v", valueAST.Value, err)
+		}
+		return f, nil
+	case *ast.ListValue:
+		list := make([]interface{}, len(valueAST.Values))
+		for i, v := range valueAST.Values {
+			item, err := jsonLiteral(v)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = item
+		}
+		return list, nil
+	case *ast.ObjectValue:
+		object := make(map[string]interface{}, len(valueAST.Fields))
+		for _, field := range valueAST.Fields {
+			v, err := jsonLiteral(field.Value)
+			if err != nil {
+				return nil, err
+			}
+			object[field.Name.Value] = v
+		}
+		return object, nil
+	}
+	return nil, unsupportedLiteral("JSONObject", valueAST)
+}
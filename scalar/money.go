@@ -0,0 +1,140 @@
+package scalar
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Money is an amount in the smallest unit of its currency, e.g. cents, so
+// amounts add up without rounding
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// validCurrency reports whether currency is an ISO 4217 code like EUR
+func validCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// validate returns an error when the currency of m is not an ISO 4217 code
+func (m Money) validate() error {
+	if !validCurrency(m.Currency) {
+		return fmt.Errorf("Money cannot represent currency %q, expected an ISO 4217 code like EUR", m.Currency)
+	}
+	return nil
+}
+
+var MoneyScalar = newScalar(
+	"Money",
+	"The `Money` scalar type represents an amount in the smallest unit of a currency with its ISO 4217 code, e.g. `{amount: 250, currency: \"EUR\"}` for 2.50 EUR.",
+	serializeMoney,
+	parseMoney,
+	parseMoneyLiteral,
+)
+
+// serializeMoney serializes a Money to an object with its amount and currency
+func serializeMoney(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case Money:
+		if err := value.validate(); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	if v, ok := deref(value); ok {
+		return serializeMoney(v)
+	}
+	return nil, unsupported("Money", value)
+}
+
+// parseMoney parses a variable object with an integer amount and a currency to a Money
+func parseMoney(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case Money:
+		return value, value.validate()
+	case map[string]interface{}:
+		if len(value) != 2 {
+			return nil, fmt.Errorf("Money cannot represent %v, expected an amount and a currency", value)
+		}
+		amount, err := moneyAmount(value["amount"])
+		if err != nil {
+			return nil, err
+		}
+		currency, _ := value["currency"].(string)
+		m := Money{Amount: amount, Currency: currency}
+		return m, m.validate()
+	}
+	return nil, unsupported("Money", value)
+}
+
+// moneyAmount returns the integer amount of a Money variable, JSON numbers
+// are accepted as long as they are whole
+func moneyAmount(value interface{}) (int64, error) {
+	switch value := value.(type) {
+	case int:
+		return int64(value), nil
+	case int64:
+		return value, nil
+	case float64:
+		if value == math.Trunc(value) && math.Abs(value) < 1<<63 {
+			return int64(value), nil
+		}
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("Money cannot represent amount %v, expected an integer in the smallest unit of the currency", value)
+}
+
+// parseMoneyLiteral parses an object literal with an integer amount and a
+// currency to a Money
+func parseMoneyLiteral(valueAST ast.Value) (interface{}, error) {
+	object, ok := valueAST.(*ast.ObjectValue)
+	if !ok {
+		return nil, unsupportedLiteral("Money", valueAST)
+	}
+	var (
+		m                      Money
+		hasAmount, hasCurrency bool
+	)
+	for _, field := range object.Fields {
+		switch value := field.Value.(type) {
+		case *ast.IntValue:
+			if field.Name.Value != "amount" {
+				return nil, fmt.Errorf("Money cannot represent field %s", field.Name.Value)
+			}
+			amount, err := strconv.ParseInt(value.Value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Money cannot represent amount %s: %v", value.Value, err)
+			}
+			m.Amount, hasAmount = amount, true
+		case *ast.StringValue:
+			if field.Name.Value != "currency" {
+				return nil, fmt.Errorf("Money cannot represent field %s", field.Name.Value)
+			}
+			m.Currency, hasCurrency = value.Value, true
+		default:
+			return nil, fmt.Errorf("Money cannot represent field %s", field.Name.Value)
+		}
+	}
+	if !hasAmount || !hasCurrency {
+		return nil, fmt.Errorf("Money cannot represent an object without an amount and a currency")
+	}
+	return m, m.validate()
+}
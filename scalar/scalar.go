@@ -2,7 +2,10 @@ package scalar
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -22,10 +25,12 @@ func NewSpecialDate(v time.Time) *SpecialDate {
 	return &SpecialDate{v}
 }
 
+// UnmarshalJSON parses a time in the DateTimeLayout format, like the
+// SpecialDate scalar does
 func (sd *SpecialDate) UnmarshalJSON(input []byte) error {
 	strInput := string(input)
 	strInput = strings.Trim(strInput, `"`)
-	newTime, err := time.Parse("2006-01-02T15:04:05", strInput)
+	newTime, err := time.Parse(DateTimeLayout, strInput)
 	if err != nil {
 		return err
 	}
@@ -34,164 +39,242 @@ func (sd *SpecialDate) UnmarshalJSON(input []byte) error {
 	return nil
 }
 
-var SpecialDateScalar = graphql.NewScalar(graphql.ScalarConfig{
-	Name:        "SpecialDate",
-	Description: "The `SpecialDate` scalar type represents an Time Object.",
-	// Serialize serializes `SpecialDate` to string.
-	Serialize: func(value interface{}) interface{} {
-		switch value := value.(type) {
-		case time.Time:
-			return value.Format(time.RFC3339)
-		case SpecialDate:
-			return value.Format(time.RFC3339)
-		case string:
-			return value
-		case int:
-			t := time.Unix(int64(value), 0)
-			return t.Format(time.RFC3339)
-		case float64:
-			t := time.Unix(int64(value), 0)
-			return t.Format(time.RFC3339)
-		default:
-			return fmt.Errorf("wrong type")
-		}
-	},
-	// ParseValue parses GraphQL variables from `string` to `SpecialDate`.
-	ParseValue: func(value interface{}) interface{} {
-		switch value := value.(type) {
-		case time.Time:
-			return value
-		case SpecialDate:
-			return value
-		case string:
-			t, err := time.Parse(time.RFC3339, value)
+// MarshalJSON formats the time in the DateTimeLayout format
+func (sd SpecialDate) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(sd.Format(DateTimeLayout))), nil
+}
+
+// newScalar returns a scalar whose functions report unsupported values as
+// errors. graphql-go expects a value from every scalar function, so a
+// serialize error is raised as a panic, which graphql-go recovers into an
+// error of the field being resolved, and a parse error returns nil, which
+// graphql-go reports as an invalid argument or variable
+func newScalar(
+	name, description string,
+	serialize func(interface{}) (interface{}, error),
+	parseValue func(interface{}) (interface{}, error),
+	parseLiteral func(ast.Value) (interface{}, error),
+) *graphql.Scalar {
+	return graphql.NewScalar(graphql.ScalarConfig{
+		Name:        name,
+		Description: description,
+		Serialize: func(value interface{}) interface{} {
+			v, err := serialize(value)
 			if err != nil {
-				return err
-			} else {
-				return t
+				panic(err)
 			}
-		case int:
-			t := time.Unix(int64(value), 0)
-			return t
-		case float64:
-			t := time.Unix(int64(value), 0)
-			return t
-		default:
-			return fmt.Errorf("wrong type")
-		}
-	},
-	// ParseLiteral parses GraphQL AST value to `SpecialDate`.
-	ParseLiteral: func(valueAST ast.Value) interface{} {
-		switch valueAST := valueAST.(type) {
-		case *ast.StringValue:
-			t, err := time.Parse(time.RFC3339, valueAST.Value)
+			return v
+		},
+		ParseValue: func(value interface{}) interface{} {
+			v, err := parseValue(value)
 			if err != nil {
-				return err
-			} else {
-				return t
-			}
-		default:
-			return fmt.Errorf("wrong type")
-		}
-	},
-})
-
-var NullScalar = graphql.NewScalar(graphql.ScalarConfig{
-	Name:        "NullScalar",
-	Description: "The `NullScalar` scalar type converts null to nil.",
-	// Serialize serializes `NullScalar` to its corresponding type from pq.
-	Serialize: func(value interface{}) interface{} {
-		switch value := value.(type) {
-		case sql.NullString:
-			if value.Valid {
-				return value.String
-			} else {
-				return nil
-			}
-		case sql.NullInt64:
-			if value.Valid {
-				return value.Int64
-			} else {
 				return nil
 			}
-		case sql.NullBool:
-			if value.Valid {
-				return value.Bool
-			} else {
-				return nil
-			}
-		case sql.NullFloat64:
-			if value.Valid {
-				return value.Float64
-			} else {
-				return nil
-			}
-		case pq.NullTime:
-			if value.Valid {
-				return value.Time
-			} else {
-				return nil
-			}
-		case uuid.NullUUID:
-			if value.Valid {
-				return value.UUID.String()
-			} else {
+			return v
+		},
+		ParseLiteral: func(valueAST ast.Value) interface{} {
+			v, err := parseLiteral(valueAST)
+			if err != nil {
 				return nil
 			}
-		case string:
-			return value
-		case float64:
-			return value
-		case bool:
-			return value
-		case uuid.UUID:
-			return value
-		case time.Time:
-			return value
-		default:
-			return fmt.Errorf("wrong type")
+			return v
+		},
+	})
+}
+
+// unsupported returns the error reported for a value a scalar cannot represent
+func unsupported(name string, value interface{}) error {
+	return fmt.Errorf("%s cannot represent %T value: %v", name, value, value)
+}
+
+// unsupportedLiteral returns the error reported for a literal a scalar cannot parse
+func unsupportedLiteral(name string, valueAST ast.Value) error {
+	if valueAST == nil {
+		return fmt.Errorf("%s cannot represent a missing literal", name)
+	}
+	return fmt.Errorf("%s cannot represent %s literal", name, valueAST.GetKind())
+}
+
+// deref returns the value a pointer points to, or nil for a nil pointer. ok
+// is false when value is not a pointer
+func deref(value interface{}) (v interface{}, ok bool) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Ptr {
+		return value, false
+	}
+	if rv.IsNil() {
+		return nil, true
+	}
+	return rv.Elem().Interface(), true
+}
+
+var SpecialDateScalar = newScalar(
+	"SpecialDate",
+	"The `SpecialDate` scalar type represents an Time Object. It is read like `DateTime` and also accepts unix seconds.",
+	serializeSpecialDate,
+	parseSpecialDate,
+	parseSpecialDateLiteral,
+)
+
+// serializeSpecialDate serializes a time, or unix seconds, to a DateTimeLayout string
+func serializeSpecialDate(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		return value.Format(DateTimeLayout), nil
+	case SpecialDate:
+		return value.Format(DateTimeLayout), nil
+	case pq.NullTime:
+		if !value.Valid {
+			return nil, nil
 		}
-	},
-	// ParseValue parses GraphQL variables from `string` to `NullScalar`.
-	ParseValue: func(value interface{}) interface{} {
-		switch value := value.(type) {
-		case string:
-			return sql.NullString{Valid: true, String: value}
-		case bool:
-			return sql.NullBool{Valid: true, Bool: value}
-		case float64:
-			return sql.NullFloat64{Valid: true, Float64: value}
-		case time.Time:
-			return pq.NullTime{Valid: true, Time: value}
-		case sql.NullString:
-			return value
-		case sql.NullBool:
-			return value
-		case sql.NullFloat64:
-			return value
-		case pq.NullTime:
-			return value
-		case uuid.NullUUID:
-			return value.UUID.String()
-		default:
-			return fmt.Errorf("wrong type")
+		return value.Time.Format(DateTimeLayout), nil
+	case string:
+		if _, err := time.Parse(DateTimeLayout, value); err != nil {
+			return nil, fmt.Errorf("SpecialDate cannot represent %q: %v", value, err)
 		}
-	},
-	// ParseLiteral parses GraphQL AST value to `NullString`.
-	ParseLiteral: func(valueAST ast.Value) interface{} {
-		switch valueAST := valueAST.(type) {
-		case *ast.StringValue:
-			return sql.NullString{Valid: true, String: valueAST.Value}
-		case *ast.BooleanValue:
-			return sql.NullBool{Valid: true, Bool: valueAST.Value}
-		case *ast.FloatValue:
-			i, err := strconv.ParseFloat(valueAST.Value, 64)
-			if err != nil {
-				return sql.NullFloat64{Valid: false, Float64: i}
-			}
-			return sql.NullFloat64{Valid: true, Float64: i}
-		default:
-			return fmt.Errorf("wrong type")
+		return value, nil
+	case int:
+		return time.Unix(int64(value), 0).Format(DateTimeLayout), nil
+	case int64:
+		return time.Unix(value, 0).Format(DateTimeLayout), nil
+	case float64:
+		return time.Unix(int64(value), 0).Format(DateTimeLayout), nil
+	}
+	if v, ok := deref(value); ok {
+		return serializeSpecialDate(v)
+	}
+	return nil, unsupported("SpecialDate", value)
+}
+
+// parseSpecialDate parses a variable, a DateTimeLayout string or unix seconds, to a time
+func parseSpecialDate(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case time.Time:
+		return value, nil
+	case SpecialDate:
+		return value, nil
+	case string:
+		t, err := time.Parse(DateTimeLayout, value)
+		if err != nil {
+			return nil, fmt.Errorf("SpecialDate cannot represent %q: %v", value, err)
+		}
+		return t, nil
+	case int:
+		return time.Unix(int64(value), 0), nil
+	case int64:
+		return time.Unix(value, 0), nil
+	case float64:
+		return time.Unix(int64(value), 0), nil
+	}
+	return nil, unsupported("SpecialDate", value)
+}
+
+// parseSpecialDateLiteral parses a DateTimeLayout string or unix seconds literal to a time
+func parseSpecialDateLiteral(valueAST ast.Value) (interface{}, error) {
+	switch valueAST := valueAST.(type) {
+	case *ast.StringValue:
+		return parseSpecialDate(valueAST.Value)
+	case *ast.IntValue:
+		i, err := strconv.ParseInt(valueAST.Value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("SpecialDate cannot represent %s: %v", valueAST.Value, err)
+		}
+		return parseSpecialDate(i)
+	}
+	return nil, unsupportedLiteral("SpecialDate", valueAST)
+}
+
+var NullScalar = newScalar(
+	"NullScalar",
+	"The `NullScalar` scalar type converts null to nil.",
+	serializeNull,
+	parseNull,
+	parseNullLiteral,
+)
+
+// serializeNull serializes a database value, nullable or not, to the value it
+// holds or nil when it is null
+func serializeNull(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, time.Time:
+		return value, nil
+	case []byte:
+		return string(value), nil
+	case uuid.UUID:
+		return value.String(), nil
+	case driver.Valuer:
+		// sql.Null*, pq.NullTime, uuid.NullUUID and the other database values
+		if v, ok := deref(value); ok && v == nil {
+			return nil, nil
 		}
-	},
-})
+		v, err := value.Value()
+		if err != nil {
+			return nil, fmt.Errorf("NullScalar cannot represent %T value: %v", value, err)
+		}
+		return serializeNull(v)
+	}
+	if v, ok := deref(value); ok {
+		return serializeNull(v)
+	}
+	return nil, unsupported("NullScalar", value)
+}
+
+// parseNull parses a variable to the nullable database value of its type
+func parseNull(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case string:
+		return sql.NullString{Valid: true, String: value}, nil
+	case bool:
+		return sql.NullBool{Valid: true, Bool: value}, nil
+	case int:
+		return sql.NullInt64{Valid: true, Int64: int64(value)}, nil
+	case int32:
+		return sql.NullInt64{Valid: true, Int64: int64(value)}, nil
+	case int64:
+		return sql.NullInt64{Valid: true, Int64: value}, nil
+	case float64:
+		return sql.NullFloat64{Valid: true, Float64: value}, nil
+	case json.Number:
+		// Variables decoded with UseNumber keep integers apart from floats
+		if i, err := value.Int64(); err == nil {
+			return sql.NullInt64{Valid: true, Int64: i}, nil
+		}
+		f, err := value.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("NullScalar cannot represent %s: %v", value, err)
+		}
+		return sql.NullFloat64{Valid: true, Float64: f}, nil
+	case time.Time:
+		return pq.NullTime{Valid: true, Time: value}, nil
+	case sql.NullString, sql.NullBool, sql.NullInt64, sql.NullFloat64, pq.NullTime, uuid.NullUUID:
+		return value, nil
+	}
+	return nil, unsupported("NullScalar", value)
+}
+
+// parseNullLiteral parses a literal to the nullable database value of its kind
+func parseNullLiteral(valueAST ast.Value) (interface{}, error) {
+	switch valueAST := valueAST.(type) {
+	case *ast.StringValue:
+		return sql.NullString{Valid: true, String: valueAST.Value}, nil
+	case *ast.BooleanValue:
+		return sql.NullBool{Valid: true, Bool: valueAST.Value}, nil
+	case *ast.IntValue:
+		i, err := strconv.ParseInt(valueAST.Value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("NullScalar cannot represent %s: %v", valueAST.Value, err)
+		}
+		return sql.NullInt64{Valid: true, Int64: i}, nil
+	case *ast.FloatValue:
+		f, err := strconv.ParseFloat(valueAST.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("NullScalar cannot represent %s: %v", valueAST.Value, err)
+		}
+		return sql.NullFloat64{Valid: true, Float64: f}, nil
+	}
+	return nil, unsupportedLiteral("NullScalar", valueAST)
+}
@@ -0,0 +1,739 @@
+package scalar
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	testTime = time.Date(2019, 3, 14, 15, 9, 26, 0, time.UTC)
+	testUUID = uuid.Must(uuid.FromString("6ba7b810-9dad-11d1-80b4-00c04fd430c8"))
+)
+
+func TestSerializeNull(t *testing.T) {
+	text := "text"
+	var nilText *string
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"nil", nil, nil, false},
+		{"string", "text", "text", false},
+		{"bool", true, true, false},
+		{"int", 7, 7, false},
+		{"int64", int64(7), int64(7), false},
+		{"float64", 1.5, 1.5, false},
+		{"time", testTime, testTime, false},
+		{"bytes", []byte("text"), "text", false},
+		{"uuid", testUUID, testUUID.String(), false},
+		{"valid NullString", sql.NullString{Valid: true, String: "text"}, "text", false},
+		{"null NullString", sql.NullString{}, nil, false},
+		{"valid NullInt64", sql.NullInt64{Valid: true, Int64: 7}, int64(7), false},
+		{"null NullInt64", sql.NullInt64{}, nil, false},
+		{"valid NullBool", sql.NullBool{Valid: true, Bool: true}, true, false},
+		{"valid NullFloat64", sql.NullFloat64{Valid: true, Float64: 1.5}, 1.5, false},
+		{"valid NullTime", pq.NullTime{Valid: true, Time: testTime}, testTime, false},
+		{"null NullTime", pq.NullTime{}, nil, false},
+		{"valid NullUUID", uuid.NullUUID{Valid: true, UUID: testUUID}, testUUID.String(), false},
+		{"null NullUUID", uuid.NullUUID{}, nil, false},
+		{"pointer", &text, "text", false},
+		{"nil pointer", nilText, nil, false},
+		{"pointer to NullInt64", &sql.NullInt64{Valid: true, Int64: 7}, int64(7), false},
+		{"nil pointer to NullInt64", (*sql.NullInt64)(nil), nil, false},
+		{"pointer to time", &testTime, testTime, false},
+		{"struct", struct{ A int }{1}, nil, true},
+		{"map", map[string]int{"a": 1}, nil, true},
+		{"slice", []int{1}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serializeNull(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("serializeNull(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("serializeNull(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNull(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"string", "text", sql.NullString{Valid: true, String: "text"}, false},
+		{"bool", false, sql.NullBool{Valid: true, Bool: false}, false},
+		{"int", 7, sql.NullInt64{Valid: true, Int64: 7}, false},
+		{"int32", int32(7), sql.NullInt64{Valid: true, Int64: 7}, false},
+		{"int64", int64(7), sql.NullInt64{Valid: true, Int64: 7}, false},
+		{"float64", 1.5, sql.NullFloat64{Valid: true, Float64: 1.5}, false},
+		{"integer number", json.Number("7"), sql.NullInt64{Valid: true, Int64: 7}, false},
+		{"float number", json.Number("1.5"), sql.NullFloat64{Valid: true, Float64: 1.5}, false},
+		{"invalid number", json.Number("x"), nil, true},
+		{"time", testTime, pq.NullTime{Valid: true, Time: testTime}, false},
+		{"NullInt64", sql.NullInt64{Valid: true, Int64: 7}, sql.NullInt64{Valid: true, Int64: 7}, false},
+		{"NullUUID", uuid.NullUUID{Valid: true, UUID: testUUID}, uuid.NullUUID{Valid: true, UUID: testUUID}, false},
+		{"map", map[string]interface{}{"a": 1}, nil, true},
+		{"list", []interface{}{"a"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNull(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNull(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNull(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNullLiteral(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   ast.Value
+		want    interface{}
+		wantErr bool
+	}{
+		{"string", &ast.StringValue{Kind: "StringValue", Value: "text"}, sql.NullString{Valid: true, String: "text"}, false},
+		{"boolean", &ast.BooleanValue{Kind: "BooleanValue", Value: true}, sql.NullBool{Valid: true, Bool: true}, false},
+		{"int", &ast.IntValue{Kind: "IntValue", Value: "7"}, sql.NullInt64{Valid: true, Int64: 7}, false},
+		{"negative int", &ast.IntValue{Kind: "IntValue", Value: "-7"}, sql.NullInt64{Valid: true, Int64: -7}, false},
+		{"int overflow", &ast.IntValue{Kind: "IntValue", Value: "92233720368547758070"}, nil, true},
+		{"float", &ast.FloatValue{Kind: "FloatValue", Value: "1.5"}, sql.NullFloat64{Valid: true, Float64: 1.5}, false},
+		{"enum", &ast.EnumValue{Kind: "EnumValue", Value: "RED"}, nil, true},
+		{"list", &ast.ListValue{Kind: "ListValue"}, nil, true},
+		{"object", &ast.ObjectValue{Kind: "ObjectValue"}, nil, true},
+		{"missing", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNullLiteral(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNullLiteral(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNullLiteral(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSerializeSpecialDate(t *testing.T) {
+	want := "2019-03-14T15:09:26Z"
+	unix := testTime.Unix()
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"nil", nil, nil, false},
+		{"time", testTime, want, false},
+		{"pointer to time", &testTime, want, false},
+		{"SpecialDate", SpecialDate{testTime}, want, false},
+		{"pointer to SpecialDate", NewSpecialDate(testTime), want, false},
+		{"valid NullTime", pq.NullTime{Valid: true, Time: testTime}, want, false},
+		{"null NullTime", pq.NullTime{}, nil, false},
+		{"string", want, want, false},
+		{"invalid string", "yesterday", nil, true},
+		{"int", int(unix), time.Unix(unix, 0).Format(time.RFC3339), false},
+		{"int64", unix, time.Unix(unix, 0).Format(time.RFC3339), false},
+		{"float64", float64(unix), time.Unix(unix, 0).Format(time.RFC3339), false},
+		{"bool", true, nil, true},
+		{"struct", struct{}{}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serializeSpecialDate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("serializeSpecialDate(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("serializeSpecialDate(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSpecialDate(t *testing.T) {
+	unix := testTime.Unix()
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    time.Time
+		wantErr bool
+	}{
+		{"string", "2019-03-14T15:09:26Z", testTime, false},
+		{"invalid string", "2019-03-14", time.Time{}, true},
+		{"time", testTime, testTime, false},
+		{"int", int(unix), testTime, false},
+		{"int64", unix, testTime, false},
+		{"float64", float64(unix), testTime, false},
+		{"bool", true, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSpecialDate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSpecialDate(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got, ok := got.(time.Time); !ok || !got.Equal(tt.want) {
+				t.Errorf("parseSpecialDate(%#v) = %#v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSpecialDateLiteral(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   ast.Value
+		want    time.Time
+		wantErr bool
+	}{
+		{"string", &ast.StringValue{Kind: "StringValue", Value: "2019-03-14T15:09:26Z"}, testTime, false},
+		{"invalid string", &ast.StringValue{Kind: "StringValue", Value: "yesterday"}, time.Time{}, true},
+		{"int", &ast.IntValue{Kind: "IntValue", Value: "1552576166"}, testTime, false},
+		{"int overflow", &ast.IntValue{Kind: "IntValue", Value: "92233720368547758070"}, time.Time{}, true},
+		{"float", &ast.FloatValue{Kind: "FloatValue", Value: "1.5"}, time.Time{}, true},
+		{"boolean", &ast.BooleanValue{Kind: "BooleanValue", Value: true}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSpecialDateLiteral(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSpecialDateLiteral(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got, ok := got.(time.Time); !ok || !got.Equal(tt.want) {
+				t.Errorf("parseSpecialDateLiteral(%#v) = %#v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestScalarErrors checks that graphql-go reports the values the scalars do
+// not support as errors instead of returning them as data
+func TestScalarErrors(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"value": &graphql.Field{
+					Type: NullScalar,
+					Args: graphql.FieldConfigArgument{
+						"kind": &graphql.ArgumentConfig{Type: graphql.String},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						if p.Args["kind"] == "unsupported" {
+							return struct{ A int }{1}, nil
+						}
+						return sql.NullInt64{Valid: true, Int64: 7}, nil
+					},
+				},
+				"echo": &graphql.Field{
+					Type: NullScalar,
+					Args: graphql.FieldConfigArgument{
+						"value": &graphql.ArgumentConfig{Type: NullScalar},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Args["value"], nil
+					},
+				},
+				"date": &graphql.Field{
+					Type: SpecialDateScalar,
+					Args: graphql.FieldConfigArgument{
+						"value": &graphql.ArgumentConfig{Type: SpecialDateScalar},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Args["value"], nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		wantData  string
+		wantError string
+	}{
+		{"supported value", `{ value }`, nil, `{"value":7}`, ""},
+		{"unsupported value", `{ value(kind: "unsupported") }`, nil, `{"value":null}`, "NullScalar cannot represent"},
+		{"int literal", `{ echo(value: 7) }`, nil, `{"echo":7}`, ""},
+		{"int variable", `query ($v: NullScalar) { echo(value: $v) }`, map[string]interface{}{"v": 7}, `{"echo":7}`, ""},
+		{"list literal", `{ echo(value: [1]) }`, nil, "", "Expected type \"NullScalar\""},
+		{"list variable", `query ($v: NullScalar) { echo(value: $v) }`, map[string]interface{}{"v": []interface{}{1}}, "", "Variable \"$v\""},
+		{"date literal", `{ date(value: 1552576166) }`, nil, `{"date":"` + time.Unix(1552576166, 0).Format(time.RFC3339) + `"}`, ""},
+		{"invalid date literal", `{ date(value: "yesterday") }`, nil, "", "Expected type \"SpecialDate\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := graphql.Do(graphql.Params{
+				Schema:         schema,
+				RequestString:  tt.query,
+				VariableValues: tt.variables,
+			})
+			if tt.wantData != "" {
+				data, err := json.Marshal(result.Data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != tt.wantData {
+					t.Errorf("data = %s, want %s", data, tt.wantData)
+				}
+			}
+			if tt.wantError == "" {
+				if result.HasErrors() {
+					t.Errorf("unexpected errors: %v", result.Errors)
+				}
+				return
+			}
+			if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, tt.wantError) {
+				t.Errorf("errors = %v, want one containing %q", result.Errors, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestSpecialDateJSON(t *testing.T) {
+	var sd SpecialDate
+	if err := json.Unmarshal([]byte(`"2019-03-14T16:09:26+01:00"`), &sd); err != nil {
+		t.Fatal(err)
+	}
+	if !sd.Equal(testTime) {
+		t.Errorf("UnmarshalJSON = %v, want %v", sd.Time, testTime)
+	}
+	if err := json.Unmarshal([]byte(`"2019-03-14T15:09:26"`), &sd); err == nil {
+		t.Error("UnmarshalJSON accepted a time without an offset")
+	}
+	b, err := json.Marshal(SpecialDate{testTime})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `"2019-03-14T15:09:26Z"` {
+		t.Errorf("MarshalJSON = %s, want %q", b, "2019-03-14T15:09:26Z")
+	}
+}
+
+func TestSerializeUUID(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"nil", nil, nil, false},
+		{"uuid", testUUID, testUUID.String(), false},
+		{"pointer to uuid", &testUUID, testUUID.String(), false},
+		{"valid NullUUID", uuid.NullUUID{Valid: true, UUID: testUUID}, testUUID.String(), false},
+		{"null NullUUID", uuid.NullUUID{}, nil, false},
+		{"string", "6BA7B810-9DAD-11D1-80B4-00C04FD430C8", testUUID.String(), false},
+		{"invalid string", "store-1", nil, true},
+		{"int", 1, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serializeUUID(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("serializeUUID(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("serializeUUID(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUUID(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		literal ast.Value
+		want    interface{}
+		wantErr bool
+	}{
+		{"string", testUUID.String(), &ast.StringValue{Kind: "StringValue", Value: testUUID.String()}, testUUID, false},
+		{"invalid string", "store-1", &ast.StringValue{Kind: "StringValue", Value: "store-1"}, nil, true},
+		{"int", 1, &ast.IntValue{Kind: "IntValue", Value: "1"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseUUID(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseUUID(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseUUID(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+			got, err = parseUUIDLiteral(tt.literal)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseUUIDLiteral(%#v) error = %v, wantErr %v", tt.literal, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseUUIDLiteral(%#v) = %#v, want %#v", tt.literal, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSerializeDateTime(t *testing.T) {
+	paris := time.FixedZone("CET", 3600)
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"nil", nil, nil, false},
+		{"utc", testTime, "2019-03-14T15:09:26Z", false},
+		{"offset", testTime.In(paris), "2019-03-14T16:09:26+01:00", false},
+		{"pointer", &testTime, "2019-03-14T15:09:26Z", false},
+		{"SpecialDate", SpecialDate{testTime}, "2019-03-14T15:09:26Z", false},
+		{"valid NullTime", pq.NullTime{Valid: true, Time: testTime}, "2019-03-14T15:09:26Z", false},
+		{"null NullTime", pq.NullTime{}, nil, false},
+		{"string", "2019-03-14T16:09:26.5+01:00", "2019-03-14T16:09:26+01:00", false},
+		{"string without offset", "2019-03-14T15:09:26", nil, true},
+		{"unix seconds", 1552576166, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serializeDateTime(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("serializeDateTime(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("serializeDateTime(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		literal ast.Value
+		want    time.Time
+		wantErr bool
+	}{
+		{"utc", "2019-03-14T15:09:26Z", &ast.StringValue{Kind: "StringValue", Value: "2019-03-14T15:09:26Z"}, testTime, false},
+		{"offset", "2019-03-14T16:09:26+01:00", &ast.StringValue{Kind: "StringValue", Value: "2019-03-14T16:09:26+01:00"}, testTime, false},
+		{"without offset", "2019-03-14T15:09:26", &ast.StringValue{Kind: "StringValue", Value: "2019-03-14T15:09:26"}, time.Time{}, true},
+		{"date", "2019-03-14", &ast.StringValue{Kind: "StringValue", Value: "2019-03-14"}, time.Time{}, true},
+		{"unix seconds", 1552576166, &ast.IntValue{Kind: "IntValue", Value: "1552576166"}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, parse := range []func() (interface{}, error){
+				func() (interface{}, error) { return parseDateTime(tt.value) },
+				func() (interface{}, error) { return parseDateTimeLiteral(tt.literal) },
+			} {
+				got, err := parse()
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("parse(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+				}
+				if tt.wantErr {
+					continue
+				}
+				if got, ok := got.(time.Time); !ok || !got.Equal(tt.want) {
+					t.Errorf("parse(%#v) = %#v, want %v", tt.value, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSerializeDate(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*3600)
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"nil", nil, nil, false},
+		{"date", Date{testTime}, "2019-03-14", false},
+		{"pointer to date", &Date{testTime}, "2019-03-14", false},
+		{"time", testTime, "2019-03-14", false},
+		{"time in its location", time.Date(2019, 3, 15, 1, 0, 0, 0, tokyo), "2019-03-15", false},
+		{"valid NullTime", pq.NullTime{Valid: true, Time: testTime}, "2019-03-14", false},
+		{"null NullTime", pq.NullTime{}, nil, false},
+		{"string", "2019-03-14", "2019-03-14", false},
+		{"invalid string", "2019-02-30", nil, true},
+		{"int", 20190314, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serializeDate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("serializeDate(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("serializeDate(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	day := Date{time.Date(2019, 3, 14, 0, 0, 0, 0, time.UTC)}
+	tests := []struct {
+		name    string
+		value   interface{}
+		literal ast.Value
+		want    interface{}
+		wantErr bool
+	}{
+		{"date", "2019-03-14", &ast.StringValue{Kind: "StringValue", Value: "2019-03-14"}, day, false},
+		{"date time", "2019-03-14T15:09:26Z", &ast.StringValue{Kind: "StringValue", Value: "2019-03-14T15:09:26Z"}, nil, true},
+		{"invalid day", "2019-02-30", &ast.StringValue{Kind: "StringValue", Value: "2019-02-30"}, nil, true},
+		{"int", 20190314, &ast.IntValue{Kind: "IntValue", Value: "20190314"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDateValue(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDateValue(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDateValue(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+			got, err = parseDateLiteral(tt.literal)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDateLiteral(%#v) error = %v, wantErr %v", tt.literal, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDateLiteral(%#v) = %#v, want %#v", tt.literal, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSerializeMoney(t *testing.T) {
+	price := Money{Amount: 250, Currency: "EUR"}
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"nil", nil, nil, false},
+		{"money", price, price, false},
+		{"pointer", &price, price, false},
+		{"invalid currency", Money{Amount: 250, Currency: "eur"}, nil, true},
+		{"int", 250, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serializeMoney(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("serializeMoney(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("serializeMoney(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	price := Money{Amount: 250, Currency: "EUR"}
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"float amount", map[string]interface{}{"amount": float64(250), "currency": "EUR"}, price, false},
+		{"int amount", map[string]interface{}{"amount": 250, "currency": "EUR"}, price, false},
+		{"number amount", map[string]interface{}{"amount": json.Number("250"), "currency": "EUR"}, price, false},
+		{"negative amount", map[string]interface{}{"amount": float64(-250), "currency": "EUR"}, Money{Amount: -250, Currency: "EUR"}, false},
+		{"fractional amount", map[string]interface{}{"amount": 2.5, "currency": "EUR"}, nil, true},
+		{"string amount", map[string]interface{}{"amount": "250", "currency": "EUR"}, nil, true},
+		{"invalid currency", map[string]interface{}{"amount": 250, "currency": "euro"}, nil, true},
+		{"missing currency", map[string]interface{}{"amount": 250}, nil, true},
+		{"extra field", map[string]interface{}{"amount": 250, "currency": "EUR", "cents": true}, nil, true},
+		{"int", 250, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMoney(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMoney(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMoney(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMoneyLiteral(t *testing.T) {
+	field := func(name string, value ast.Value) *ast.ObjectField {
+		return &ast.ObjectField{Kind: "ObjectField", Name: &ast.Name{Kind: "Name", Value: name}, Value: value}
+	}
+	amount := field("amount", &ast.IntValue{Kind: "IntValue", Value: "250"})
+	currency := field("currency", &ast.StringValue{Kind: "StringValue", Value: "EUR"})
+	tests := []struct {
+		name    string
+		value   ast.Value
+		want    interface{}
+		wantErr bool
+	}{
+		{"object", &ast.ObjectValue{Kind: "ObjectValue", Fields: []*ast.ObjectField{amount, currency}}, Money{Amount: 250, Currency: "EUR"}, false},
+		{"float amount", &ast.ObjectValue{Kind: "ObjectValue", Fields: []*ast.ObjectField{
+			field("amount", &ast.FloatValue{Kind: "FloatValue", Value: "2.5"}), currency,
+		}}, nil, true},
+		{"invalid currency", &ast.ObjectValue{Kind: "ObjectValue", Fields: []*ast.ObjectField{
+			amount, field("currency", &ast.StringValue{Kind: "StringValue", Value: "€"}),
+		}}, nil, true},
+		{"missing amount", &ast.ObjectValue{Kind: "ObjectValue", Fields: []*ast.ObjectField{currency}}, nil, true},
+		{"unknown field", &ast.ObjectValue{Kind: "ObjectValue", Fields: []*ast.ObjectField{
+			amount, currency, field("cents", &ast.IntValue{Kind: "IntValue", Value: "1"}),
+		}}, nil, true},
+		{"int", &ast.IntValue{Kind: "IntValue", Value: "250"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMoneyLiteral(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMoneyLiteral(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMoneyLiteral(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSerializeJSONObject(t *testing.T) {
+	type jsonMap map[string]interface{}
+	object := map[string]interface{}{"weight": 250, "organic": true}
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"nil", nil, nil, false},
+		{"map", object, object, false},
+		{"named map", jsonMap(object), object, false},
+		{"nil named map", jsonMap(nil), nil, false},
+		{"pointer to named map", &jsonMap{"weight": 250}, map[string]interface{}{"weight": 250}, false},
+		{"string", "text", nil, true},
+		{"map of ints", map[int]interface{}{1: "a"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serializeJSONObject(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("serializeJSONObject(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("serializeJSONObject(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONObjectRoundTrip(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"echo": &graphql.Field{
+					Type: JSONObjectScalar,
+					Args: graphql.FieldConfigArgument{
+						"value": &graphql.ArgumentConfig{Type: JSONObjectScalar},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Args["value"], nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const object = `{"organic":true,"origin":{"country":"KE","farms":[1,2]},"rating":4.5,"tags":["fair",7,false],"weight":250}`
+	var variable map[string]interface{}
+	if err := json.Unmarshal([]byte(object), &variable); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		wantData  string
+		wantError string
+	}{
+		{
+			"literal",
+			`{ echo(value: {weight: 250, rating: 4.5, organic: true, tags: ["fair", 7, false], origin: {country: "KE", farms: [1, 2]}}) }`,
+			nil, `{"echo":` + object + `}`, "",
+		},
+		{"variable", `query ($v: JSONObject) { echo(value: $v) }`, map[string]interface{}{"v": variable}, `{"echo":` + object + `}`, ""},
+		{"empty object", `{ echo(value: {}) }`, nil, `{"echo":{}}`, ""},
+		{"string literal", `{ echo(value: "text") }`, nil, "", "Expected type \"JSONObject\""},
+		{"list literal", `{ echo(value: [1]) }`, nil, "", "Expected type \"JSONObject\""},
+		{"list variable", `query ($v: JSONObject) { echo(value: $v) }`, map[string]interface{}{"v": []interface{}{1}}, "", "Variable \"$v\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := graphql.Do(graphql.Params{
+				Schema:         schema,
+				RequestString:  tt.query,
+				VariableValues: tt.variables,
+			})
+			if tt.wantData != "" {
+				data, err := json.Marshal(result.Data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != tt.wantData {
+					t.Errorf("data = %s, want %s", data, tt.wantData)
+				}
+			}
+			if tt.wantError == "" {
+				if result.HasErrors() {
+					t.Errorf("unexpected errors: %v", result.Errors)
+				}
+				return
+			}
+			if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, tt.wantError) {
+				t.Errorf("errors = %v, want one containing %q", result.Errors, tt.wantError)
+			}
+		})
+	}
+}
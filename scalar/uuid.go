@@ -0,0 +1,64 @@
+package scalar
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql/language/ast"
+	uuid "github.com/satori/go.uuid"
+)
+
+var UUIDScalar = newScalar(
+	"UUID",
+	"The `UUID` scalar type represents a UUID in its canonical string form, e.g. `6ba7b810-9dad-11d1-80b4-00c04fd430c8`.",
+	serializeUUID,
+	parseUUID,
+	parseUUIDLiteral,
+)
+
+// serializeUUID serializes a UUID, or a string holding one, to its canonical form
+func serializeUUID(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case uuid.UUID:
+		return value.String(), nil
+	case uuid.NullUUID:
+		if !value.Valid {
+			return nil, nil
+		}
+		return value.UUID.String(), nil
+	case string:
+		id, err := parseUUID(value)
+		if err != nil {
+			return nil, err
+		}
+		return id.(uuid.UUID).String(), nil
+	}
+	if v, ok := deref(value); ok {
+		return serializeUUID(v)
+	}
+	return nil, unsupported("UUID", value)
+}
+
+// parseUUID parses a variable to a uuid.UUID
+func parseUUID(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case uuid.UUID:
+		return value, nil
+	case string:
+		id, err := uuid.FromString(value)
+		if err != nil {
+			return nil, fmt.Errorf("UUID cannot represent %q: %v", value, err)
+		}
+		return id, nil
+	}
+	return nil, unsupported("UUID", value)
+}
+
+// parseUUIDLiteral parses a string literal to a uuid.UUID
+func parseUUIDLiteral(valueAST ast.Value) (interface{}, error) {
+	if valueAST, ok := valueAST.(*ast.StringValue); ok {
+		return parseUUID(valueAST.Value)
+	}
+	return nil, unsupportedLiteral("UUID", valueAST)
+}
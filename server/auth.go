@@ -0,0 +1,73 @@
+package server
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"go-graphql-cloud-api/ciphers"
+)
+
+// ErrUnauthenticated is returned by an Authenticator when the request
+// could not be attributed to any known client
+var ErrUnauthenticated = errors.New("request signature could not be verified")
+
+// Authenticator verifies the signature of a request, given the payload that
+// was signed such as the SignedPayload of a graphql request, and returns the
+// identity of the client that sent it
+type Authenticator interface {
+	Authenticate(payload string, signature string) (string, error)
+}
+
+// SignedPayload returns what a client signs for a request, so that a signature
+// cannot be replayed with other variables or another operation. It is the JSON
+// object of the query, variables and operationName with the keys sorted, the
+// empty ones left out, no whitespace and HTML characters left unescaped, e.g.
+// {"operationName":"Vendor","query":"query Vendor($id: UUID!) { vendor(id: $id) { id } }","variables":{"id":"6ba7b810-9dad-11d1-80b4-00c04fd430c8"}}
+func SignedPayload(query string, variables map[string]interface{}, operationName string) string {
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	// Fields are in the order of their keys, and encoding/json sorts the keys
+	// of the variables. Variables decoded from JSON always encode
+	e.Encode(struct {
+		OperationName string                 `json:"operationName,omitempty"`
+		Query         string                 `json:"query"`
+		Variables     map[string]interface{} `json:"variables,omitempty"`
+	}{operationName, query, variables})
+	return string(bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+}
+
+// SignatureAuthenticator verifies the base64 encoded PKCS#1 v1.5 signature of
+// the payload of a request against the public keys of the registered clients
+type SignatureAuthenticator struct {
+	// Keys maps a client identity to the public key it signs requests with
+	Keys map[string]*rsa.PublicKey
+}
+
+// NewSignatureAuthenticator returns a SignatureAuthenticator for the given clients
+func NewSignatureAuthenticator(keys map[string]*rsa.PublicKey) *SignatureAuthenticator {
+	return &SignatureAuthenticator{Keys: keys}
+}
+
+// Authenticate returns the identity of the first client, in the order of the
+// identities, whose public key verifies the signature, otherwise
+// ErrUnauthenticated. Clients sharing a key are thus always identified alike
+func (a *SignatureAuthenticator) Authenticate(payload string, signature string) (string, error) {
+	if signature == "" {
+		return "", ErrUnauthenticated
+	}
+	clientIDs := make([]string, 0, len(a.Keys))
+	for clientID := range a.Keys {
+		clientIDs = append(clientIDs, clientID)
+	}
+	sort.Strings(clientIDs)
+	for _, clientID := range clientIDs {
+		if ciphers.VerifyWithPublicKey(signature, payload, *a.Keys[clientID]) {
+			return clientID, nil
+		}
+	}
+	return "", ErrUnauthenticated
+}
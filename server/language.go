@@ -0,0 +1,45 @@
+package server
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// acceptLanguages returns the language tags of an Accept-Language header,
+// most preferred first. The wildcard and tags with a zero quality are dropped
+func acceptLanguages(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag, q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].q > tags[j].q
+	})
+
+	langs := make([]string, len(tags))
+	for i, t := range tags {
+		langs[i] = t.tag
+	}
+	return langs
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAcceptLanguages(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   []string
+	}{
+		{"missing", "", []string{}},
+		{"single", "zh-HK", []string{"zh-HK"}},
+		{"header order", "en, zh", []string{"en", "zh"}},
+		{"quality order", "en;q=0.5, zh-HK, zh;q=0.8", []string{"zh-HK", "zh", "en"}},
+		{"equal qualities keep the header order", "fr;q=0.7, en;q=0.7, zh", []string{"zh", "fr", "en"}},
+		{"wildcard", "zh, *;q=0.5", []string{"zh"}},
+		{"wildcard alone", "*", []string{}},
+		{"zero quality", "en, zh;q=0", []string{"en"}},
+		{"spaces around parameters", " zh ; q=0.3 ,en ; q=0.9 ", []string{"en", "zh"}},
+		{"other parameters", "en;level=1;q=0.2, zh", []string{"zh", "en"}},
+		{"malformed quality", "en;q=high, zh;q=0.5", []string{"en", "zh"}},
+		{"empty entries", ",, en,;q=0.5,", []string{"en"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := acceptLanguages(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("acceptLanguages(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
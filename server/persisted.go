@@ -0,0 +1,237 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go-graphql-cloud-api/postgres"
+)
+
+// persistedQueryError is an error of the persisted query protocol, it is
+// returned to the client as a graphql error which clients recognize by its
+// message
+type persistedQueryError string
+
+func (e persistedQueryError) Error() string {
+	return string(e)
+}
+
+const (
+	// ErrPersistedQueryNotFound asks the client to send the query along with its hash
+	ErrPersistedQueryNotFound persistedQueryError = "PersistedQueryNotFound"
+	// ErrPersistedQueryNotSupported tells the client not to send hashes
+	ErrPersistedQueryNotSupported persistedQueryError = "PersistedQueryNotSupported"
+	// ErrPersistedQueryNotAllowed refuses a query missing from the allow-list
+	ErrPersistedQueryNotAllowed persistedQueryError = "PersistedQueryNotAllowed"
+	// ErrPersistedQueryMismatch refuses a query sent with the hash of another query
+	ErrPersistedQueryMismatch persistedQueryError = "provided sha does not match query"
+)
+
+// persistedQuery is the persistedQuery extension of a request
+type persistedQuery struct {
+	Version    int    `json:"version"`
+	Sha256Hash string `json:"sha256Hash"`
+}
+
+// DefaultMaxPersistedQueries is how many queries registered by clients are
+// kept in memory by default
+const DefaultMaxPersistedQueries = 1000
+
+// PersistedQueries executes requests sending the sha256 hash of a query
+// instead of the query itself, following the automatic persisted queries
+// (APQ) protocol. A client sends the hash alone and, when the query is not
+// known yet, sends it again along with the query to register it
+type PersistedQueries struct {
+	// AllowList only executes the registered queries, clients can no
+	// longer register queries themselves
+	AllowList bool
+	// MaxQueries caps the queries kept in memory unless AllowList is set,
+	// as clients register them. The least recently used are forgotten first
+	// and are then looked up in Db again, no cap applies when it is 0
+	MaxQueries int
+	// Db shares the registered queries with the other instances when set,
+	// they are only kept in memory otherwise
+	Db *postgres.Db
+
+	mu sync.Mutex
+	// queries holds the elements of recent by hash
+	queries map[string]*list.Element
+	// recent orders the cachedQuery values from the most recently used
+	recent *list.List
+}
+
+// cachedQuery is a query kept in memory
+type cachedQuery struct {
+	hash  string
+	query string
+}
+
+// NewPersistedQueries returns PersistedQueries keeping the queries in memory,
+// at most DefaultMaxPersistedQueries of them unless allowList is set
+func NewPersistedQueries(allowList bool) *PersistedQueries {
+	return &PersistedQueries{
+		AllowList:  allowList,
+		MaxQueries: DefaultMaxPersistedQueries,
+		queries:    make(map[string]*list.Element),
+		recent:     list.New(),
+	}
+}
+
+// queryHash returns the hex encoded sha256 hash APQ clients identify query by
+func queryHash(query string) string {
+	b, _ := base64.StdEncoding.DecodeString(HashSha256(query))
+	return hex.EncodeToString(b)
+}
+
+// parseHash returns the hex encoded form of a sha256 hash, which clients may
+// also send base64 encoded like HashSha256 returns it
+func parseHash(hash string) (string, error) {
+	b, err := hex.DecodeString(hash)
+	if err != nil {
+		b, err = base64.StdEncoding.DecodeString(hash)
+	}
+	if err != nil || len(b) != 32 {
+		return "", persistedQueryError(fmt.Sprintf("invalid sha256Hash %q", hash))
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Register adds a query to the registered queries and returns its hash
+func (p *PersistedQueries) Register(ctx context.Context, query string) (string, error) {
+	hash := queryHash(query)
+	if p.Db != nil {
+		if err := p.Db.SavePersistedQuery(ctx, hash, query); err != nil {
+			return hash, err
+		}
+	}
+	p.remember(hash, query)
+	return hash, nil
+}
+
+// Load reads the queries registered in the database into memory
+func (p *PersistedQueries) Load(ctx context.Context) error {
+	if p.Db == nil {
+		return nil
+	}
+	queries, err := p.Db.ListPersistedQueries(ctx)
+	if err != nil {
+		return err
+	}
+	for hash, query := range queries {
+		p.remember(strings.ToLower(hash), query)
+	}
+	return nil
+}
+
+// remember keeps a query in memory as the most recently used, forgetting the
+// least recently used beyond MaxQueries
+func (p *PersistedQueries) remember(hash string, query string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e, ok := p.queries[hash]; ok {
+		e.Value = cachedQuery{hash: hash, query: query}
+		p.recent.MoveToFront(e)
+		return
+	}
+	p.queries[hash] = p.recent.PushFront(cachedQuery{hash: hash, query: query})
+	if p.AllowList || p.MaxQueries <= 0 {
+		return
+	}
+	for p.recent.Len() > p.MaxQueries {
+		oldest := p.recent.Remove(p.recent.Back()).(cachedQuery)
+		delete(p.queries, oldest.hash)
+	}
+}
+
+// cached returns the query registered with hash that is kept in memory, ok
+// is false when there is none
+func (p *PersistedQueries) cached(hash string) (query string, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.queries[hash]
+	if !ok {
+		return "", false
+	}
+	p.recent.MoveToFront(e)
+	return e.Value.(cachedQuery).query, true
+}
+
+// lookup returns the query registered with hash, ok is false when there is
+// none. It may query the database, so it is only used for authenticated requests
+func (p *PersistedQueries) lookup(ctx context.Context, hash string) (query string, ok bool, err error) {
+	query, ok = p.cached(hash)
+	if ok || p.Db == nil {
+		return query, ok, nil
+	}
+
+	// Another instance may have registered it
+	query, ok, err = p.Db.GetPersistedQuery(ctx, hash)
+	if ok {
+		p.remember(hash, query)
+	}
+	return query, ok, err
+}
+
+// Resolve returns the query to execute for a request sending query and
+// extension, either of which may be empty. Errors of the protocol are
+// returned as a persistedQueryError.
+//
+// A request sending the hash alone is resolved before it is authenticated, so
+// its query is only looked up in memory. A query registered by another
+// instance or forgotten beyond MaxQueries is then not found, and the client
+// sends it again along with the hash, which is looked up in the database once
+// the request is authenticated
+func (p *PersistedQueries) Resolve(ctx context.Context, query string, extension *persistedQuery) (string, error) {
+	if extension == nil {
+		if !p.AllowList {
+			return query, nil
+		}
+		_, ok, err := p.lookup(ctx, queryHash(query))
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", ErrPersistedQueryNotAllowed
+		}
+		return query, nil
+	}
+
+	if extension.Version != 1 {
+		return "", persistedQueryError(fmt.Sprintf("unsupported persisted query version %d", extension.Version))
+	}
+	hash, err := parseHash(extension.Sha256Hash)
+	if err != nil {
+		return "", err
+	}
+
+	if query == "" {
+		query, ok := p.cached(hash)
+		if !ok {
+			return "", ErrPersistedQueryNotFound
+		}
+		return query, nil
+	}
+
+	if queryHash(query) != hash {
+		return "", ErrPersistedQueryMismatch
+	}
+	if p.AllowList {
+		_, ok, err := p.lookup(ctx, hash)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", ErrPersistedQueryNotAllowed
+		}
+		return query, nil
+	}
+	if _, err := p.Register(ctx, query); err != nil {
+		return "", err
+	}
+	return query, nil
+}
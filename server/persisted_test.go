@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	testQuery      = "{ vendors { id } }"
+	testOtherQuery = "{ stores { id } }"
+)
+
+func TestParseHash(t *testing.T) {
+	hash := queryHash(testQuery)
+	tests := []struct {
+		name    string
+		hash    string
+		want    string
+		wantErr bool
+	}{
+		{"hex", hash, hash, false},
+		{"upper case hex", strings.ToUpper(hash), hash, false},
+		{"base64", HashSha256(testQuery), hash, false},
+		{"empty", "", "", true},
+		{"short hex", hash[:32], "", true},
+		{"short base64", "dGV4dA==", "", true},
+		{"neither hex nor base64", "not a hash", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHash(tt.hash)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseHash(%q) error = %v, wantErr %v", tt.hash, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseHash(%q) = %q, want %q", tt.hash, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPersistedQueriesResolve(t *testing.T) {
+	extension := func(hash string) *persistedQuery {
+		return &persistedQuery{Version: 1, Sha256Hash: hash}
+	}
+	tests := []struct {
+		name      string
+		allowList bool
+		query     string
+		extension *persistedQuery
+		want      string
+		wantErr   error
+	}{
+		{"query", false, testOtherQuery, nil, testOtherQuery, nil},
+		{"registered query on the allow-list", true, testQuery, nil, testQuery, nil},
+		{"query missing from the allow-list", true, testOtherQuery, nil, "", ErrPersistedQueryNotAllowed},
+		{"hash of a registered query", false, "", extension(queryHash(testQuery)), testQuery, nil},
+		{"base64 hash of a registered query", false, "", extension(HashSha256(testQuery)), testQuery, nil},
+		{"hash of an unknown query", false, "", extension(queryHash(testOtherQuery)), "", ErrPersistedQueryNotFound},
+		{"hash missing from the allow-list", true, "", extension(queryHash(testOtherQuery)), "", ErrPersistedQueryNotFound},
+		{"hash registering a query", false, testOtherQuery, extension(queryHash(testOtherQuery)), testOtherQuery, nil},
+		{"base64 hash registering a query", false, testOtherQuery, extension(HashSha256(testOtherQuery)), testOtherQuery, nil},
+		{"hash of a query on the allow-list", true, testQuery, extension(queryHash(testQuery)), testQuery, nil},
+		{"hash of a query missing from the allow-list", true, testOtherQuery, extension(queryHash(testOtherQuery)), "", ErrPersistedQueryNotAllowed},
+		{"hash of another query", false, testOtherQuery, extension(queryHash(testQuery)), "", ErrPersistedQueryMismatch},
+		{"hash of another query on the allow-list", true, testOtherQuery, extension(queryHash(testQuery)), "", ErrPersistedQueryMismatch},
+		{"invalid hash", false, "", extension("not a hash"), "", persistedQueryError(`invalid sha256Hash "not a hash"`)},
+		{"unsupported version", false, "", &persistedQuery{Version: 2, Sha256Hash: queryHash(testQuery)}, "", persistedQueryError("unsupported persisted query version 2")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPersistedQueries(tt.allowList)
+			if _, err := p.Register(context.Background(), testQuery); err != nil {
+				t.Fatalf("Register(%q) error = %v", testQuery, err)
+			}
+			got, err := p.Resolve(context.Background(), tt.query, tt.extension)
+			if err != tt.wantErr {
+				t.Fatalf("Resolve(%q, %+v) error = %v, want %v", tt.query, tt.extension, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve(%q, %+v) = %q, want %q", tt.query, tt.extension, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPersistedQueriesResolveRegisters(t *testing.T) {
+	p := NewPersistedQueries(false)
+	hash := &persistedQuery{Version: 1, Sha256Hash: queryHash(testQuery)}
+	if _, err := p.Resolve(context.Background(), "", hash); err != ErrPersistedQueryNotFound {
+		t.Fatalf("Resolve of an unknown hash error = %v, want %v", err, ErrPersistedQueryNotFound)
+	}
+	if _, err := p.Resolve(context.Background(), testQuery, hash); err != nil {
+		t.Fatalf("Resolve of the query and its hash error = %v", err)
+	}
+	got, err := p.Resolve(context.Background(), "", hash)
+	if err != nil || got != testQuery {
+		t.Errorf("Resolve of a registered hash = %q, %v, want %q", got, err, testQuery)
+	}
+}
+
+func TestPersistedQueriesMaxQueries(t *testing.T) {
+	ctx := context.Background()
+	query := func(i int) string { return fmt.Sprintf("{ vendor(id: %d) { id } }", i) }
+	hash := func(i int) *persistedQuery { return &persistedQuery{Version: 1, Sha256Hash: queryHash(query(i))} }
+
+	p := NewPersistedQueries(false)
+	p.MaxQueries = 3
+	for i := 0; i < 3; i++ {
+		if _, err := p.Register(ctx, query(i)); err != nil {
+			t.Fatalf("Register(%q) error = %v", query(i), err)
+		}
+	}
+	// Using the first query makes the second the least recently used
+	if _, err := p.Resolve(ctx, "", hash(0)); err != nil {
+		t.Fatalf("Resolve of a registered hash error = %v", err)
+	}
+	if _, err := p.Register(ctx, query(3)); err != nil {
+		t.Fatalf("Register(%q) error = %v", query(3), err)
+	}
+	for i, want := range []error{nil, ErrPersistedQueryNotFound, nil, nil} {
+		if _, err := p.Resolve(ctx, "", hash(i)); err != want {
+			t.Errorf("Resolve of the hash of query %d error = %v, want %v", i, err, want)
+		}
+	}
+
+	allowList := NewPersistedQueries(true)
+	allowList.MaxQueries = 3
+	for i := 0; i < 4; i++ {
+		if _, err := allowList.Register(ctx, query(i)); err != nil {
+			t.Fatalf("Register(%q) error = %v", query(i), err)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := allowList.Resolve(ctx, "", hash(i)); err != nil {
+			t.Errorf("Resolve of the hash of query %d on the allow-list error = %v", i, err)
+		}
+	}
+}
+
+// testAuthenticator accepts the requests signed "valid"
+type testAuthenticator struct{}
+
+func (testAuthenticator) Authenticate(payload string, signature string) (string, error) {
+	if signature != "valid" {
+		return "", errors.New("invalid signature")
+	}
+	return "test", nil
+}
+
+func TestGraphQLProbingHashes(t *testing.T) {
+	p := NewPersistedQueries(false)
+	if _, err := p.Register(context.Background(), testQuery); err != nil {
+		t.Fatalf("Register(%q) error = %v", testQuery, err)
+	}
+	s := &Server{
+		NewContext:       func(ctx context.Context) context.Context { return ctx },
+		Authenticator:    testAuthenticator{},
+		PersistedQueries: p,
+	}
+	post := func(body string) (int, string) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		s.GraphQL()(w, r)
+		b, _ := ioutil.ReadAll(w.Result().Body)
+		return w.Code, string(b)
+	}
+	hashOnly := func(query string, signature string) string {
+		return fmt.Sprintf(`{"extensions":{"persistedQuery":{"version":1,"sha256Hash":%q}},"signature":%q}`, queryHash(query), signature)
+	}
+
+	wantCode, want := post(hashOnly(testOtherQuery, "valid"))
+	if !strings.Contains(want, string(ErrPersistedQueryNotFound)) {
+		t.Fatalf("unknown hash response = %d %s, want %s", wantCode, want, ErrPersistedQueryNotFound)
+	}
+	for _, signature := range []string{"", "invalid"} {
+		if code, got := post(hashOnly(testQuery, signature)); code != wantCode || got != want {
+			t.Errorf("registered hash signed %q response = %d %s, want %d %s", signature, code, got, wantCode, want)
+		}
+	}
+	if code, _ := post(fmt.Sprintf(`{"query":%q,"signature":"invalid"}`, testQuery)); code != http.StatusUnauthorized {
+		t.Errorf("query signed %q response status = %d, want %d", "invalid", code, http.StatusUnauthorized)
+	}
+}
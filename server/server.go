@@ -5,22 +5,54 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"go-graphql-cloud-api/gql"
+	"go-graphql-cloud-api/postgres"
+	"io/ioutil"
+	"mime"
 	"net/http"
 
 	"github.com/go-chi/render"
 	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
 )
 
 // Server will hold connection to the db as well as handlers
 type Server struct {
+	Db        *postgres.Db
 	GqlSchema *graphql.Schema
-	Context   *context.Context
+	// NewContext derives the context a single request is executed with
+	NewContext func(context.Context) context.Context
+	// Subscribe starts a subscription delivering its results until the
+	// context is done
+	Subscribe func(context.Context, graphql.Schema, gql.Request) (<-chan *graphql.Result, error)
+	// Authenticator verifies every request before it is executed, requests
+	// are executed unauthenticated when it is nil
+	Authenticator Authenticator
+	// PersistedQueries executes the queries requests send the hash of, the
+	// full query is required when it is nil
+	PersistedQueries *PersistedQueries
+	// AllowedOrigins are the origins, such as https://dashboard.example.com,
+	// of the pages other than our own that may open subscriptions
+	AllowedOrigins []string
+
+	// telemetryReplays refuses telemetry reports that were received already
+	telemetryReplays replayGuard
 }
 
+// reqBody is a graphql request as sent over HTTP, along with the signature
+// of its SignedPayload
 type reqBody struct {
-	Query     string `json:"query"`
-	Signature string `json:"signature"`
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+	Signature     string                 `json:"signature"`
+	Extensions    struct {
+		PersistedQuery *persistedQuery `json:"persistedQuery"`
+	} `json:"extensions"`
 }
 
 func HashSha256(msg string) string {
@@ -30,43 +62,195 @@ func HashSha256(msg string) string {
 	return base64.StdEncoding.EncodeToString(bs)
 }
 
+// verify verifies the signature of payload and returns ctx carrying the
+// verified client identity
+func (s *Server) verify(ctx context.Context, payload string, signature string) (context.Context, error) {
+	if s.Authenticator == nil {
+		return ctx, nil
+	}
+	clientID, err := s.Authenticator.Authenticate(payload, signature)
+	if err != nil {
+		return ctx, err
+	}
+	// Make the verified client available to our resolvers
+	return context.WithValue(ctx, "clientID", clientID), nil
+}
+
+// authenticate verifies the signature of payload like verify. It responds
+// with a 401 and returns false when the request cannot be verified
+func (s *Server) authenticate(ctx context.Context, w http.ResponseWriter, payload string, signature string) (context.Context, bool) {
+	ctx, err := s.verify(ctx, payload, signature)
+	if err != nil {
+		fmt.Println(err)
+		http.Error(w, "Authentication Error", 401)
+		return ctx, false
+	}
+	return ctx, true
+}
+
 // GraphQL returns an http.HandlerFunc for our /graphql endpoint
 func (s *Server) GraphQL() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Check to ensure query was provided in the request body
-		if r.Body == nil {
-			http.Error(w, "Must provide graphql query in request body", 400)
+		rBody, err := parseRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
 			return
 		}
 
-		var rBody reqBody
-		// Decode the request body into rBody
-		err := json.NewDecoder(r.Body).Decode(&rBody)
-		if err != nil {
-			http.Error(w, "Error parsing JSON request body", 400)
+		// Every request gets its own dataloaders and is cancelled with the client
+		ctx := s.NewContext(r.Context())
+		// Localized fields prefer the languages the client accepts
+		ctx = context.WithValue(ctx, "langs", acceptLanguages(r.Header.Get("Accept-Language")))
+		// A request sending only the hash of a persisted query is signed like
+		// the query itself, so the query is looked up before authenticating.
+		// That lookup never reaches the database
+		query := rBody.Query
+		if query == "" {
+			if query, err = s.resolveQuery(ctx, rBody); err != nil {
+				s.persistedQueryFailed(w, r, err)
+				return
+			}
+		}
+		// The signature covers the variables and operation name as well, so
+		// it cannot be replayed with others
+		payload := SignedPayload(query, rBody.Variables, rBody.OperationName)
+		if rBody.Query == "" && rBody.Extensions.PersistedQuery != nil {
+			// Unverified requests sending a hash are answered like unknown
+			// hashes, so hashes cannot be probed without a valid signature.
+			// The client then sends the query, which is refused as usual
+			if ctx, err = s.verify(ctx, payload, rBody.Signature); err != nil {
+				fmt.Println(err)
+				s.persistedQueryFailed(w, r, ErrPersistedQueryNotFound)
+				return
+			}
+		} else {
+			var ok bool
+			if ctx, ok = s.authenticate(ctx, w, payload, rBody.Signature); !ok {
+				return
+			}
+		}
+		// Only authenticated clients may register a query
+		if rBody.Query != "" {
+			if query, err = s.resolveQuery(ctx, rBody); err != nil {
+				s.persistedQueryFailed(w, r, err)
+				return
+			}
 		}
 
-		// Authentication here
-		// auth := rBody.Signature
-		// publicKey := ciphers.LoadRSAPublicPemKey("public.pem")
-		// verified := ciphers.VerifyWithPublicKey(auth, rBody.Query, *publicKey)
-
-		// fmt.Println("rBody.Query: ", rBody.Query)
-		// fmt.Println("Signature: ", auth)
-		// fmt.Println("verified: ", verified)
+		// GET requests must not change anything, e.g. when a link is followed
+		if r.Method == http.MethodGet && !isQuery(query, rBody.OperationName) {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Only queries can be sent with GET", 405)
+			return
+		}
 
-		// Check if signature == query
-		// if err != nil || !verified {
-		// 	fmt.Println(err)
-		// 	http.Error(w, "Authentication Error", 401)
-		// } else {
 		// Execute graphql query
-		result := gql.ExecuteQuery(rBody.Query, *s.GqlSchema, *s.Context)
+		result := gql.ExecuteQuery(gql.Request{
+			Query:         query,
+			Variables:     rBody.Variables,
+			OperationName: rBody.OperationName,
+		}, *s.GqlSchema, ctx)
 
 		// render.JSON comes from the chi/render package and handles
 		// marshalling to json, automatically escaping HTML and setting
 		// the Content-Type as application/json.
 		render.JSON(w, r, result)
-		// }
 	}
 }
+
+// parseRequest reads the graphql request from the URL of a GET request, or
+// from the body of a POST request, which is JSON or, with the
+// application/graphql content type, the query itself. The signature is sent
+// in the X-Signature header, or in the JSON body. It is never read from the
+// URL, which ends up in logs and Referer headers
+func parseRequest(r *http.Request) (reqBody, error) {
+	var rBody reqBody
+	switch {
+	case r.Method == http.MethodGet:
+		params := r.URL.Query()
+		rBody.Query = params.Get("query")
+		rBody.OperationName = params.Get("operationName")
+		if variables := params.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &rBody.Variables); err != nil {
+				return rBody, errors.New("Error parsing JSON variables")
+			}
+		}
+		if extensions := params.Get("extensions"); extensions != "" {
+			if err := json.Unmarshal([]byte(extensions), &rBody.Extensions); err != nil {
+				return rBody, errors.New("Error parsing JSON extensions")
+			}
+		}
+	case r.Body == nil:
+		// Check to ensure query was provided in the request body
+		return rBody, errors.New("Must provide graphql query in request body")
+	case mediaType(r) == "application/graphql":
+		query, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return rBody, errors.New("Error reading request body")
+		}
+		rBody.Query = string(query)
+	default:
+		// Decode the request body into rBody
+		if err := json.NewDecoder(r.Body).Decode(&rBody); err != nil {
+			return rBody, errors.New("Error parsing JSON request body")
+		}
+	}
+	if rBody.Signature == "" {
+		rBody.Signature = r.Header.Get("X-Signature")
+	}
+	return rBody, nil
+}
+
+// mediaType returns the media type of the body of r without its parameters
+func mediaType(r *http.Request) string {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType
+}
+
+// isQuery reports whether the operation of query named operationName, or its
+// only operation, is a query. A query that does not parse is left for
+// graphql to report
+func isQuery(query string, operationName string) bool {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return true
+	}
+	for _, definition := range doc.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" || operation.Name != nil && operation.Name.Value == operationName {
+			if operation.Operation != ast.OperationTypeQuery {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// resolveQuery returns the query to execute for the request, looking up or
+// registering its persisted query
+func (s *Server) resolveQuery(ctx context.Context, rBody reqBody) (string, error) {
+	if s.PersistedQueries == nil {
+		if rBody.Extensions.PersistedQuery != nil {
+			return "", ErrPersistedQueryNotSupported
+		}
+		return rBody.Query, nil
+	}
+	return s.PersistedQueries.Resolve(ctx, rBody.Query, rBody.Extensions.PersistedQuery)
+}
+
+// persistedQueryFailed responds to a request whose persisted query could not
+// be resolved. Errors of the protocol are returned as graphql errors for the
+// client to react to
+func (s *Server) persistedQueryFailed(w http.ResponseWriter, r *http.Request, err error) {
+	if _, ok := err.(persistedQueryError); !ok {
+		fmt.Println(err)
+		http.Error(w, "Error resolving persisted query", 500)
+		return
+	}
+	render.JSON(w, r, &graphql.Result{
+		Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(err.Error())},
+	})
+}
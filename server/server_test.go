@@ -0,0 +1,258 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-graphql-cloud-api/ciphers"
+)
+
+func TestIsQuery(t *testing.T) {
+	const operations = "query Vendors { vendors { id } } mutation Refill { refillStore { id } }"
+	tests := []struct {
+		name          string
+		query         string
+		operationName string
+		want          bool
+	}{
+		{"shorthand query", "{ vendors { id } }", "", true},
+		{"query", "query { vendors { id } }", "", true},
+		{"mutation", "mutation { refillStore { id } }", "", false},
+		{"subscription", "subscription { storeStatusChanged { status } }", "", false},
+		{"named query", operations, "Vendors", true},
+		{"named mutation", operations, "Refill", false},
+		{"any mutation without an operation name", operations, "", false},
+		{"unknown operation name", operations, "Other", true},
+		{"query with a fragment", "query { vendors { ...v } } fragment v on Vendor { id }", "", true},
+		{"invalid query", "mutation {", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isQuery(tt.query, tt.operationName); got != tt.want {
+				t.Errorf("isQuery(%q, %q) = %v, want %v", tt.query, tt.operationName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediaType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		want        string
+	}{
+		{"json", "application/json", "application/json"},
+		{"json with charset", "application/json; charset=utf-8", "application/json"},
+		{"graphql", "application/graphql", "application/graphql"},
+		{"graphql with charset", "application/graphql;charset=UTF-8", "application/graphql"},
+		{"upper case", "Application/GraphQL", "application/graphql"},
+		{"missing", "", ""},
+		{"invalid parameter", "application/graphql; charset", "application/graphql"},
+		{"invalid", "/graphql", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			if got := mediaType(r); got != tt.want {
+				t.Errorf("mediaType(%q) = %q, want %q", tt.contentType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	get := func(params url.Values, header http.Header) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/graphql?"+params.Encode(), nil)
+		for name, values := range header {
+			r.Header[name] = values
+		}
+		return r
+	}
+	post := func(contentType, body string, header http.Header) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		for name, values := range header {
+			r.Header[name] = values
+		}
+		return r
+	}
+	signed := http.Header{"X-Signature": {"header"}}
+	hashOnly := reqBody{}
+	hashOnly.Extensions.PersistedQuery = &persistedQuery{Version: 1, Sha256Hash: "abc"}
+	tests := []struct {
+		name    string
+		r       *http.Request
+		want    reqBody
+		wantErr bool
+	}{
+		{
+			"GET",
+			get(url.Values{"query": {"{ vendors { id } }"}, "operationName": {"Vendors"}, "variables": {`{"first":2}`}}, signed),
+			reqBody{Query: "{ vendors { id } }", OperationName: "Vendors", Variables: map[string]interface{}{"first": float64(2)}, Signature: "header"},
+			false,
+		},
+		{
+			"GET ignores a signature in the URL",
+			get(url.Values{"query": {"{ vendors { id } }"}, "signature": {"url"}}, nil),
+			reqBody{Query: "{ vendors { id } }"},
+			false,
+		},
+		{
+			"GET with extensions",
+			get(url.Values{"extensions": {`{"persistedQuery":{"version":1,"sha256Hash":"abc"}}`}}, nil),
+			hashOnly,
+			false,
+		},
+		{"GET with invalid variables", get(url.Values{"variables": {"{"}}, nil), reqBody{}, true},
+		{"GET with invalid extensions", get(url.Values{"extensions": {"["}}, nil), reqBody{}, true},
+		{
+			"JSON",
+			post("application/json", `{"query":"{ vendors { id } }","variables":{"first":2},"operationName":"Vendors","signature":"body"}`, signed),
+			reqBody{Query: "{ vendors { id } }", OperationName: "Vendors", Variables: map[string]interface{}{"first": float64(2)}, Signature: "body"},
+			false,
+		},
+		{
+			"JSON signed in the header",
+			post("application/json", `{"query":"{ vendors { id } }"}`, signed),
+			reqBody{Query: "{ vendors { id } }", Signature: "header"},
+			false,
+		},
+		{
+			"JSON without a content type",
+			post("", `{"query":"{ vendors { id } }"}`, nil),
+			reqBody{Query: "{ vendors { id } }"},
+			false,
+		},
+		{
+			"JSON with extensions",
+			post("application/json", `{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"abc"}}}`, nil),
+			hashOnly,
+			false,
+		},
+		{"invalid JSON", post("application/json", `{"query":`, nil), reqBody{}, true},
+		{
+			"graphql",
+			post("application/graphql; charset=utf-8", "{ vendors { id } }", signed),
+			reqBody{Query: "{ vendors { id } }", Signature: "header"},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRequest(tt.r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRequest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRequestWithoutBody(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	r.Body = nil
+	if _, err := parseRequest(r); err == nil {
+		t.Error("parseRequest() of a request without a body error = nil, want an error")
+	}
+}
+
+func TestSignedPayload(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		variables     map[string]interface{}
+		operationName string
+		want          string
+	}{
+		{"query", "{ vendors { id } }", nil, "", `{"query":"{ vendors { id } }"}`},
+		{"empty variables", "{ vendors { id } }", map[string]interface{}{}, "", `{"query":"{ vendors { id } }"}`},
+		{
+			"variables and operation name",
+			"query Vendors($first: Int) { vendors(first: $first) { id } }",
+			map[string]interface{}{"first": float64(2), "after": "<cursor>"},
+			"Vendors",
+			`{"operationName":"Vendors","query":"query Vendors($first: Int) { vendors(first: $first) { id } }","variables":{"after":"<cursor>","first":2}}`,
+		},
+		{
+			"nested variables",
+			"mutation ($slots: [SlotRefillArgs!]!) { refillStore(slots: $slots) { id } }",
+			map[string]interface{}{"slots": []interface{}{map[string]interface{}{"slot": float64(1), "quantity": float64(3)}}},
+			"",
+			`{"query":"mutation ($slots: [SlotRefillArgs!]!) { refillStore(slots: $slots) { id } }","variables":{"slots":[{"quantity":3,"slot":1}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SignedPayload(tt.query, tt.variables, tt.operationName); got != tt.want {
+				t.Errorf("SignedPayload() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignatureAuthenticator(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewSignatureAuthenticator(map[string]*rsa.PublicKey{
+		"store":  &key.PublicKey,
+		"kiosk":  &key.PublicKey,
+		"portal": &otherKey.PublicKey,
+	})
+	variables := map[string]interface{}{"id": "6ba7b810-9dad-11d1-80b4-00c04fd430c8"}
+	payload := SignedPayload("query Vendor($id: UUID!) { vendor(id: $id) { id } }", variables, "Vendor")
+	signature := ciphers.SignWithPrivateKey(payload, *key)
+	tests := []struct {
+		name      string
+		payload   string
+		signature string
+		want      string
+		wantErr   error
+	}{
+		{"first client sharing the key", payload, signature, "kiosk", nil},
+		{"other key", payload, ciphers.SignWithPrivateKey(payload, *otherKey), "portal", nil},
+		{"missing signature", payload, "", "", ErrUnauthenticated},
+		{"invalid signature", payload, "not a signature", "", ErrUnauthenticated},
+		{
+			"other variables",
+			SignedPayload("query Vendor($id: UUID!) { vendor(id: $id) { id } }", map[string]interface{}{"id": "00000000-0000-0000-0000-000000000000"}, "Vendor"),
+			signature, "", ErrUnauthenticated,
+		},
+		{
+			"other operation name",
+			SignedPayload("query Vendor($id: UUID!) { vendor(id: $id) { id } }", variables, "Other"),
+			signature, "", ErrUnauthenticated,
+		},
+		{"query alone", "query Vendor($id: UUID!) { vendor(id: $id) { id } }", signature, "", ErrUnauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Authenticate(tt.payload, tt.signature)
+			if err != tt.wantErr {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Authenticate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go-graphql-cloud-api/gql"
+
+	"github.com/gorilla/websocket"
+)
+
+// Message types of the graphql-ws protocol
+const (
+	gqlConnectionInit      = "connection_init"
+	gqlConnectionAck       = "connection_ack"
+	gqlConnectionError     = "connection_error"
+	gqlConnectionKeepAlive = "ka"
+	gqlConnectionTerminate = "connection_terminate"
+	gqlStart               = "start"
+	gqlStop                = "stop"
+	gqlData                = "data"
+	gqlError               = "error"
+	gqlComplete            = "complete"
+)
+
+// keepAliveInterval is how often idle connections are told we are still there
+const keepAliveInterval = 15 * time.Second
+
+// checkOrigin reports whether a WebSocket may be opened by the page that
+// sent r. Browsers send the Origin of the page, which must be the origin of
+// the api itself or one of AllowedOrigins, so no other website can open a
+// socket on behalf of its visitors. Clients other than browsers send no Origin
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range s.AllowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// operationMessage is a message of the graphql-ws protocol
+type operationMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// startPayload is the payload of a start message, signed like the body of a
+// POST request
+type startPayload struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+	Signature     string                 `json:"signature"`
+}
+
+// subscriptionConn is a WebSocket connection serving subscriptions
+type subscriptionConn struct {
+	ws *websocket.Conn
+	// mu serializes the writes to ws
+	mu sync.Mutex
+	// operationsMu guards operations, which the subscriptions remove
+	// themselves from when they complete
+	operationsMu sync.Mutex
+	// operations cancels the running subscriptions by id
+	operations map[string]*operation
+}
+
+// operation is a running subscription of a connection
+type operation struct {
+	stop context.CancelFunc
+}
+
+// addOperation registers a running subscription under id
+func (c *subscriptionConn) addOperation(id string, op *operation) {
+	c.operationsMu.Lock()
+	defer c.operationsMu.Unlock()
+	c.operations[id] = op
+}
+
+// stopOperation stops the subscription registered under id and frees the id.
+// When op is not nil, the subscription is only stopped if it is still the
+// one registered, as the id may have been reused since
+func (c *subscriptionConn) stopOperation(id string, op *operation) {
+	c.operationsMu.Lock()
+	defer c.operationsMu.Unlock()
+	current, ok := c.operations[id]
+	if !ok || op != nil && current != op {
+		return
+	}
+	current.stop()
+	delete(c.operations, id)
+}
+
+// inUse reports whether a running subscription is registered under id
+func (c *subscriptionConn) inUse(id string) bool {
+	c.operationsMu.Lock()
+	defer c.operationsMu.Unlock()
+	_, ok := c.operations[id]
+	return ok
+}
+
+// send writes a message to the client, payload is encoded as JSON
+func (c *subscriptionConn) send(id string, messageType string, payload interface{}) {
+	message := operationMessage{ID: id, Type: messageType}
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		message.Payload = b
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.ws.WriteJSON(message); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// sendError reports an operation that could not be started
+func (c *subscriptionConn) sendError(id string, err error) {
+	c.send(id, gqlError, map[string]string{"message": err.Error()})
+}
+
+// Subscriptions returns an http.HandlerFunc serving graphql subscriptions over
+// WebSocket with the graphql-ws protocol
+func (s *Server) Subscriptions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{
+			Subprotocols: []string{"graphql-ws"},
+			CheckOrigin:  s.checkOrigin,
+		}
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade has already replied with an error
+			fmt.Println(err)
+			return
+		}
+		defer ws.Close()
+
+		// Every subscription of the connection ends with it
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		// Localized fields prefer the languages the client accepts
+		ctx = context.WithValue(ctx, "langs", acceptLanguages(r.Header.Get("Accept-Language")))
+
+		c := &subscriptionConn{ws: ws, operations: make(map[string]*operation)}
+		// Operations may only start once the connection is acknowledged
+		acknowledged := false
+		for {
+			var message operationMessage
+			if err := ws.ReadJSON(&message); err != nil {
+				if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					fmt.Println(err)
+				}
+				return
+			}
+
+			switch message.Type {
+			case gqlConnectionInit:
+				if acknowledged {
+					c.send("", gqlConnectionError, map[string]string{"message": "connection is already initialized"})
+					continue
+				}
+				acknowledged = true
+				c.send("", gqlConnectionAck, nil)
+				go c.keepAlive(ctx)
+			case gqlStart:
+				if !acknowledged {
+					c.sendError(message.ID, errors.New("connection_init must be acknowledged before start"))
+					continue
+				}
+				s.startSubscription(ctx, c, message)
+			case gqlStop:
+				c.stopOperation(message.ID, nil)
+			case gqlConnectionTerminate:
+				return
+			default:
+				c.send(message.ID, gqlConnectionError, map[string]string{"message": "unknown message type " + message.Type})
+			}
+		}
+	}
+}
+
+// startSubscription authenticates and starts the operation of a start message,
+// delivering its results until it is stopped or the connection closes
+func (s *Server) startSubscription(ctx context.Context, c *subscriptionConn, message operationMessage) {
+	if s.Subscribe == nil {
+		c.sendError(message.ID, errors.New("subscriptions are not supported"))
+		return
+	}
+	if message.ID == "" || c.inUse(message.ID) {
+		c.sendError(message.ID, fmt.Errorf("operation id %q is missing or already in use", message.ID))
+		return
+	}
+	var payload startPayload
+	if err := json.Unmarshal(message.Payload, &payload); err != nil {
+		c.sendError(message.ID, errors.New("Error parsing start payload"))
+		return
+	}
+	if s.Authenticator != nil {
+		clientID, err := s.Authenticator.Authenticate(SignedPayload(payload.Query, payload.Variables, payload.OperationName), payload.Signature)
+		if err != nil {
+			fmt.Println(err)
+			c.sendError(message.ID, errors.New("Authentication Error"))
+			return
+		}
+		// Make the verified client available to our resolvers
+		ctx = context.WithValue(ctx, "clientID", clientID)
+	}
+
+	operationCtx, stop := context.WithCancel(ctx)
+	results, err := s.Subscribe(operationCtx, *s.GqlSchema, gql.Request{
+		Query:         payload.Query,
+		Variables:     payload.Variables,
+		OperationName: payload.OperationName,
+	})
+	if err != nil {
+		stop()
+		c.sendError(message.ID, err)
+		return
+	}
+	op := &operation{stop: stop}
+	// Starts are handled one at a time, so the id is still free
+	c.addOperation(message.ID, op)
+
+	go func() {
+		for result := range results {
+			c.send(message.ID, gqlData, result)
+		}
+		// Free the id of a subscription that ended by itself
+		c.stopOperation(message.ID, op)
+		// There is no one left to tell once the connection is closed
+		if ctx.Err() == nil {
+			c.send(message.ID, gqlComplete, nil)
+		}
+	}()
+}
+
+// keepAlive tells the client the connection is alive until ctx is done
+func (c *subscriptionConn) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.send("", gqlConnectionKeepAlive, nil)
+		}
+	}
+}
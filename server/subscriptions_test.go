@@ -0,0 +1,103 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	s := &Server{AllowedOrigins: []string{"https://dashboard.example.com/", "http://localhost:3000"}}
+	tests := []struct {
+		name   string
+		origin string
+		want   bool
+	}{
+		{"no origin", "", true},
+		{"same host", "https://api.example.com", true},
+		{"same host in another case", "https://API.example.com", true},
+		{"allowed", "https://dashboard.example.com", true},
+		{"allowed in another case", "HTTPS://Dashboard.example.com", true},
+		{"allowed with a port", "http://localhost:3000", true},
+		{"allowed host with another scheme", "http://dashboard.example.com", false},
+		{"allowed host with another port", "http://localhost:8080", false},
+		{"other site", "https://evil.example.net", false},
+		{"suffix of an allowed origin", "https://dashboard.example.com.evil.net", false},
+		{"null", "null", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "https://api.example.com/subscriptions", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := s.checkOrigin(r); got != tt.want {
+				t.Errorf("checkOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+// dialSubscriptions opens a graphql-ws connection to ts with origin
+func dialSubscriptions(ts *httptest.Server, origin string) (*websocket.Conn, *http.Response, error) {
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-ws"}}
+	header := http.Header{}
+	if origin != "" {
+		header.Set("Origin", origin)
+	}
+	return dialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), header)
+}
+
+func TestSubscriptionsRejectsOrigin(t *testing.T) {
+	ts := httptest.NewServer((&Server{}).Subscriptions())
+	defer ts.Close()
+
+	ws, resp, err := dialSubscriptions(ts, "https://evil.example.net")
+	if err == nil {
+		ws.Close()
+		t.Fatal("Dial() from another origin succeeded, want an error")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Dial() from another origin response = %v, want status %d", resp, http.StatusForbidden)
+	}
+}
+
+func TestSubscriptionsStartBeforeInit(t *testing.T) {
+	ts := httptest.NewServer((&Server{}).Subscriptions())
+	defer ts.Close()
+
+	ws, _, err := dialSubscriptions(ts, ts.URL)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer ws.Close()
+
+	expect := func(want operationMessage) {
+		t.Helper()
+		var got operationMessage
+		if err := ws.ReadJSON(&got); err != nil {
+			t.Fatalf("ReadJSON() error = %v", err)
+		}
+		if got.ID != want.ID || got.Type != want.Type {
+			t.Fatalf("received %s %q, want %s %q", got.Type, got.ID, want.Type, want.ID)
+		}
+	}
+
+	if err := ws.WriteJSON(operationMessage{ID: "1", Type: gqlStart, Payload: []byte(`{"query":"subscription { orderSubmitted { id } }"}`)}); err != nil {
+		t.Fatal(err)
+	}
+	expect(operationMessage{ID: "1", Type: gqlError})
+
+	if err := ws.WriteJSON(operationMessage{Type: gqlConnectionInit}); err != nil {
+		t.Fatal(err)
+	}
+	expect(operationMessage{Type: gqlConnectionAck})
+
+	if err := ws.WriteJSON(operationMessage{Type: gqlConnectionInit}); err != nil {
+		t.Fatal(err)
+	}
+	expect(operationMessage{Type: gqlConnectionError})
+}
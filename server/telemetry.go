@@ -0,0 +1,152 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-graphql-cloud-api/postgres"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+)
+
+// telemetryMaxAge is how far the sent_at of a telemetry report may be from
+// now, older reports are refused as replays
+const telemetryMaxAge = 5 * time.Minute
+
+// telemetryBody is the body of a telemetry report, unset values leave the
+// store untouched. The store_id and sent_at are signed along with the report
+// so it cannot be replayed against another store or later on
+type telemetryBody struct {
+	StoreID               string     `json:"store_id"`
+	SentAt                *time.Time `json:"sent_at"`
+	Event                 string     `json:"event"`
+	Online                *bool      `json:"online"`
+	SyncedAt              *time.Time `json:"synced_at"`
+	LastGet               *time.Time `json:"last_get"`
+	LastRefill            *time.Time `json:"last_refill"`
+	LastReset             *time.Time `json:"last_reset"`
+	UnsubmittedOrderCount *int64     `json:"unsubmitted_order_count"`
+}
+
+func nullTime(t *time.Time) pq.NullTime {
+	if t == nil {
+		return pq.NullTime{}
+	}
+	return pq.NullTime{Time: *t, Valid: true}
+}
+
+// replayGuard remembers the signatures of the requests accepted within
+// telemetryMaxAge, so each of them is only accepted once
+type replayGuard struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// accept reports whether signature was not seen yet and remembers it until
+// expires, forgetting the signatures that expired already
+func (g *replayGuard) accept(signature string, expires time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	if g.seen == nil {
+		g.seen = make(map[string]time.Time)
+	}
+	for seen, expiry := range g.seen {
+		if now.After(expiry) {
+			delete(g.seen, seen)
+		}
+	}
+	if _, ok := g.seen[signature]; ok {
+		return false
+	}
+	g.seen[signature] = expires
+	return true
+}
+
+// StoreTelemetry returns an http.HandlerFunc for our /stores/{id}/telemetry
+// endpoint, letting devices report their state without building a graphql
+// query. The raw body is signed like a query, with the signature sent in the
+// X-Signature header. It must hold the store_id of the URL and a sent_at
+// within telemetryMaxAge of now, and is only accepted once
+func (s *Server) StoreTelemetry() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		storeID, err := uuid.FromString(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid store id", 400)
+			return
+		}
+		if r.Body == nil {
+			http.Error(w, "Must provide telemetry in request body", 400)
+			return
+		}
+		raw, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Error reading request body", 400)
+			return
+		}
+
+		signature := r.Header.Get("X-Signature")
+		ctx, ok := s.authenticate(r.Context(), w, string(raw), signature)
+		if !ok {
+			return
+		}
+
+		var body telemetryBody
+		if err = json.Unmarshal(raw, &body); err != nil {
+			http.Error(w, "Error parsing JSON request body", 400)
+			return
+		}
+		if !uuid.Equal(uuid.FromStringOrNil(body.StoreID), storeID) {
+			http.Error(w, "Telemetry store_id does not match the URL", 400)
+			return
+		}
+		if body.SentAt == nil || time.Since(*body.SentAt) > telemetryMaxAge || time.Until(*body.SentAt) > telemetryMaxAge {
+			http.Error(w, "Telemetry sent_at is missing or stale", 400)
+			return
+		}
+		if !s.telemetryReplays.accept(signature, body.SentAt.Add(telemetryMaxAge)) {
+			http.Error(w, "Telemetry report was already received", 409)
+			return
+		}
+		t := postgres.StoreTelemetry{
+			StoreID:    storeID,
+			Event:      body.Event,
+			Online:     body.Online == nil || *body.Online,
+			SyncedAt:   nullTime(body.SyncedAt),
+			LastGet:    nullTime(body.LastGet),
+			LastRefill: nullTime(body.LastRefill),
+			LastReset:  nullTime(body.LastReset),
+		}
+		if t.Event == "" {
+			t.Event = postgres.TelemetryHeartbeat
+		}
+		if body.UnsubmittedOrderCount != nil {
+			t.UnsubmittedOrderCount = sql.NullInt64{Int64: *body.UnsubmittedOrderCount, Valid: true}
+		}
+
+		if err = t.Validate(); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+
+		store, err := s.Db.RecordStoreTelemetry(ctx, t)
+		if _, ok := err.(postgres.NotFoundError); ok {
+			http.Error(w, "Store not found", 404)
+			return
+		}
+		if err != nil {
+			fmt.Println(err)
+			http.Error(w, "Error recording telemetry", 500)
+			return
+		}
+		render.JSON(w, r, store)
+	}
+}